@@ -0,0 +1,36 @@
+package discover
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Bodies use the same small length-prefixed encoding as wire (4-byte
+// big-endian lengths): every field is a fixed-size value or is
+// length-prefixed, which is all a handful of RPC messages need.
+
+func appendBytes(buf, field []byte) []byte {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+	buf = append(buf, length[:]...)
+	return append(buf, field...)
+}
+
+func appendString(buf []byte, s string) []byte { return appendBytes(buf, []byte(s)) }
+
+func takeBytes(data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("discover: truncated length prefix")
+	}
+	length := binary.BigEndian.Uint32(data[:4])
+	data = data[4:]
+	if uint32(len(data)) < length {
+		return nil, nil, fmt.Errorf("discover: truncated field (want %d, have %d)", length, len(data))
+	}
+	return data[:length], data[length:], nil
+}
+
+func takeString(data []byte) (string, []byte, error) {
+	b, rest, err := takeBytes(data)
+	return string(b), rest, err
+}
@@ -0,0 +1,271 @@
+package discover
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ElysicConstructor/golang-p2p/wire"
+)
+
+// lookupAlpha is how many unqueried shortlist nodes an iterative lookup
+// round queries concurrently, matching Kademlia's usual alpha=3.
+const lookupAlpha = 3
+
+// lookupSize is how many candidates a lookup keeps and how many
+// contacts a NEIGHBORS reply carries — "the ~20 closest peers" a room
+// rendezvous is after.
+const lookupSize = 20
+
+// lookupRounds bounds how many query rounds a Lookup performs, so a
+// network with gaps in its routing tables can't spin forever chasing
+// contacts that never improve the shortlist.
+const lookupRounds = 4
+
+// rpcTimeout bounds how long any single PING or FIND_NODE waits for a
+// reply before treating the peer as unreachable.
+const rpcTimeout = 500 * time.Millisecond
+
+// Discovery runs one node's side of the DHT: it answers PING and
+// FIND_NODE from other peers, and drives PING/FIND_NODE of its own to
+// bootstrap and to perform lookups.
+type Discovery struct {
+	self  Node
+	table *Table
+	send  func(addr *net.UDPAddr, frame []byte)
+
+	mu       sync.Mutex
+	pending  map[string]chan neighbors // addr -> outstanding FIND_NODE reply
+	pongWait map[string]chan struct{}  // addr -> outstanding PING reply
+}
+
+// NewDiscovery creates a Discovery for self, registering the wire
+// handlers needed to participate in the DHT. send is expected to write
+// a wire-encoded frame to addr, the same send function peers already
+// use for everything else.
+func NewDiscovery(self Node, send func(addr *net.UDPAddr, frame []byte)) *Discovery {
+	d := &Discovery{
+		self:     self,
+		table:    NewTable(self.ID),
+		send:     send,
+		pending:  make(map[string]chan neighbors),
+		pongWait: make(map[string]chan struct{}),
+	}
+	wire.RegisterCodec(wire.CodePing, d.handlePing)
+	wire.RegisterCodec(wire.CodePong, d.handlePong)
+	wire.RegisterCodec(CodeFindNode, d.handleFindNode)
+	wire.RegisterCodec(CodeNeighbors, d.handleNeighbors)
+	return d
+}
+
+// Bootstrap seeds the routing table from a list of known peer
+// addresses (any peer, not a special introducer) and then performs a
+// self-lookup to pull in everything those seeds know about the network
+// around us.
+func (d *Discovery) Bootstrap(seeds []string) error {
+	var lastErr error
+	reached := 0
+	for _, s := range seeds {
+		addr, err := net.ResolveUDPAddr("udp", s)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		d.findNode(addr, d.self.ID)
+		reached++
+	}
+	if reached == 0 {
+		return fmt.Errorf("discover: no usable seed nodes: %w", lastErr)
+	}
+	d.SelfLookup()
+	return nil
+}
+
+// SelfLookup performs an iterative lookup of our own ID, which is the
+// standard Kademlia way of populating nearby buckets right after
+// joining.
+func (d *Discovery) SelfLookup() { d.Lookup(d.self.ID) }
+
+// Rendezvous finds the peers currently closest to room's rendezvous
+// key, i.e. whoever is best positioned to help a newcomer find the rest
+// of that room without a dedicated introducer.
+func (d *Discovery) Rendezvous(room string) []Node {
+	return d.Lookup(RoomKey(room))
+}
+
+// Lookup iteratively queries the network for the nodes closest to
+// target, starting from our own routing table and converging on
+// whichever peers those peers' tables point to, round after round.
+func (d *Discovery) Lookup(target NodeID) []Node {
+	seen := map[NodeID]bool{d.self.ID: true}
+	queried := make(map[NodeID]bool) // nodes we've already sent a FIND_NODE to this lookup
+	shortlist := d.table.Closest(target, lookupSize)
+	for _, n := range shortlist {
+		seen[n.ID] = true
+	}
+
+	for round := 0; round < lookupRounds; round++ {
+		var toQuery []Node
+		for _, n := range shortlist {
+			if queried[n.ID] {
+				continue
+			}
+			toQuery = append(toQuery, n)
+			if len(toQuery) >= lookupAlpha {
+				break
+			}
+		}
+		if len(toQuery) == 0 {
+			break
+		}
+		for _, n := range toQuery {
+			queried[n.ID] = true
+		}
+
+		discovered := make(map[NodeID]Node)
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, n := range toQuery {
+			wg.Add(1)
+			go func(n Node) {
+				defer wg.Done()
+				for _, got := range d.findNode(n.Addr, target) {
+					if seen[got.ID] {
+						continue
+					}
+					mu.Lock()
+					discovered[got.ID] = got
+					mu.Unlock()
+				}
+			}(n)
+		}
+		wg.Wait()
+
+		if len(discovered) == 0 {
+			break
+		}
+		for id, n := range discovered {
+			seen[id] = true
+			shortlist = append(shortlist, n)
+		}
+		sort.Slice(shortlist, func(i, j int) bool {
+			return less(distance(shortlist[i].ID, target), distance(shortlist[j].ID, target))
+		})
+		if len(shortlist) > lookupSize {
+			shortlist = shortlist[:lookupSize]
+		}
+	}
+	return shortlist
+}
+
+// Ping checks whether addr is still alive, the way a full
+// implementation would before evicting it from a full bucket in favor
+// of a newer contact.
+func (d *Discovery) Ping(addr *net.UDPAddr) bool {
+	key := addr.String()
+	ch := make(chan struct{}, 1)
+	d.mu.Lock()
+	d.pongWait[key] = ch
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.pongWait, key)
+		d.mu.Unlock()
+	}()
+
+	d.send(addr, wire.Encode(wire.CodePing, 0, nil))
+	select {
+	case <-ch:
+		return true
+	case <-time.After(rpcTimeout):
+		return false
+	}
+}
+
+// findNode sends a FIND_NODE to addr and waits for its NEIGHBORS reply,
+// inserting every contact it learns about into our own table along the
+// way — so a lookup improves our routing table even for nodes outside
+// its own result set.
+func (d *Discovery) findNode(addr *net.UDPAddr, target NodeID) []Node {
+	key := addr.String()
+	reply := make(chan neighbors, 1)
+	d.mu.Lock()
+	d.pending[key] = reply
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.pending, key)
+		d.mu.Unlock()
+	}()
+
+	body := findNode{Requester: d.self.ID, RequesterPub: d.self.Pub, Target: target}.encode()
+	d.send(addr, wire.Encode(CodeFindNode, 0, body))
+
+	select {
+	case n := <-reply:
+		nodes := make([]Node, 0, len(n.Entries))
+		for _, e := range n.Entries {
+			a, err := net.ResolveUDPAddr("udp", e.Addr)
+			if err != nil {
+				continue
+			}
+			node := Node{ID: e.ID, Addr: a, Pub: e.Pub}
+			d.table.Insert(node)
+			nodes = append(nodes, node)
+		}
+		return nodes
+	case <-time.After(rpcTimeout):
+		return nil
+	}
+}
+
+func (d *Discovery) handlePing(from *net.UDPAddr, body []byte) {
+	d.send(from, wire.Encode(wire.CodePong, 0, nil))
+}
+
+func (d *Discovery) handlePong(from *net.UDPAddr, body []byte) {
+	d.mu.Lock()
+	ch, ok := d.pongWait[from.String()]
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Discovery) handleFindNode(from *net.UDPAddr, body []byte) {
+	f, err := decodeFindNode(body)
+	if err != nil {
+		return
+	}
+	d.table.Insert(Node{ID: f.Requester, Addr: from, Pub: f.RequesterPub})
+
+	closest := d.table.Closest(f.Target, lookupSize)
+	entries := make([]neighborEntry, 0, len(closest))
+	for _, n := range closest {
+		entries = append(entries, neighborEntry{ID: n.ID, Addr: n.Addr.String(), Pub: n.Pub})
+	}
+	d.send(from, wire.Encode(CodeNeighbors, 0, neighbors{Entries: entries}.encode()))
+}
+
+func (d *Discovery) handleNeighbors(from *net.UDPAddr, body []byte) {
+	n, err := decodeNeighbors(body)
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	ch, ok := d.pending[from.String()]
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- n:
+	default:
+	}
+}
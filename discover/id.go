@@ -0,0 +1,67 @@
+// Package discover implements a small Kademlia-style distributed hash
+// table so the network no longer depends on one hardcoded introducer:
+// any peer can answer PING/FIND_NODE RPCs, and a room is located by an
+// iterative lookup of blake2b("room:"+roomName) instead of a query to a
+// single well-known address.
+package discover
+
+import (
+	"crypto/ed25519"
+	"math/bits"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// idBits is the width of a NodeID in bits, which is also the number of
+// buckets a Table keeps: bucket i covers XOR-distance [2^i, 2^(i+1)).
+const idBits = 256
+
+// NodeID is a peer's position in the DHT keyspace, derived from its
+// static identity rather than chosen freely, so a peer cannot grind for
+// an ID close to a target it wants to eclipse.
+type NodeID [32]byte
+
+// IDFromPubKey derives the NodeID for a peer's ed25519 public key.
+func IDFromPubKey(pub ed25519.PublicKey) NodeID {
+	return blake2b.Sum256(pub)
+}
+
+// RoomKey derives the NodeID a room's rendezvous lookup targets, distinct
+// from any real peer's ID by the "room:" domain prefix.
+func RoomKey(room string) NodeID {
+	return blake2b.Sum256(append([]byte("room:"), room...))
+}
+
+// distance is the XOR metric Kademlia measures closeness by: smaller is
+// closer, and it is a valid metric (symmetric, satisfies the triangle
+// inequality) without needing any notion of geography.
+func distance(a, b NodeID) NodeID {
+	var d NodeID
+	for i := range d {
+		d[i] = a[i] ^ b[i]
+	}
+	return d
+}
+
+// bucketIndex returns which of a Table's idBits buckets id falls into
+// relative to self: the index of the highest set bit of their XOR
+// distance, i.e. floor(log2(distance)). Identical IDs have no bucket.
+func bucketIndex(self, id NodeID) int {
+	d := distance(self, id)
+	for i, b := range d {
+		if b == 0 {
+			continue
+		}
+		return (len(d)-1-i)*8 + bits.Len8(b) - 1
+	}
+	return -1
+}
+
+func less(a, b NodeID) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package discover
+
+import (
+	"fmt"
+
+	"github.com/ElysicConstructor/golang-p2p/wire"
+)
+
+// wire's core enum already reserves CodePing/CodePong for exactly this
+// use. FindNode/Neighbors have no core slot, so they take a reserved
+// range of their own the same way pubsub and nat do.
+const (
+	CodeFindNode wire.Code = 0x3000 + iota
+	CodeNeighbors
+)
+
+// ping and pong carry no fields: a PONG with the right source address is
+// itself the only information either side needs.
+
+// findNode is the body of a CodeFindNode frame: a request for the nodes
+// the recipient knows about that are closest to Target. It also carries
+// the requester's own ID and pubkey, the same way a real Kademlia node
+// learns about a querier from the query itself rather than needing a
+// separate announcement.
+type findNode struct {
+	Requester    NodeID
+	RequesterPub []byte
+	Target       NodeID
+}
+
+func (f findNode) encode() []byte {
+	out := make([]byte, 0, len(f.Requester)+4+len(f.RequesterPub)+len(f.Target))
+	out = append(out, f.Requester[:]...)
+	out = appendBytes(out, f.RequesterPub)
+	out = append(out, f.Target[:]...)
+	return out
+}
+
+func decodeFindNode(body []byte) (findNode, error) {
+	var f findNode
+	if len(body) < len(f.Requester) {
+		return findNode{}, fmt.Errorf("discover: malformed FIND_NODE body")
+	}
+	copy(f.Requester[:], body[:len(f.Requester)])
+	rest := body[len(f.Requester):]
+
+	var err error
+	if f.RequesterPub, rest, err = takeBytes(rest); err != nil {
+		return findNode{}, err
+	}
+	if len(rest) != len(f.Target) {
+		return findNode{}, fmt.Errorf("discover: malformed FIND_NODE target")
+	}
+	copy(f.Target[:], rest)
+	return f, nil
+}
+
+// neighborEntry is one contact in a neighbors reply.
+type neighborEntry struct {
+	ID   NodeID
+	Addr string
+	Pub  []byte
+}
+
+// neighbors is the body of a CodeNeighbors frame: the reply to a
+// FIND_NODE, carrying the responder's closest known contacts.
+type neighbors struct {
+	Entries []neighborEntry
+}
+
+func (n neighbors) encode() []byte {
+	out := make([]byte, 0, 1+len(n.Entries)*64)
+	out = append(out, byte(len(n.Entries)))
+	for _, e := range n.Entries {
+		out = append(out, e.ID[:]...)
+		out = appendString(out, e.Addr)
+		out = appendBytes(out, e.Pub)
+	}
+	return out
+}
+
+func decodeNeighbors(body []byte) (neighbors, error) {
+	if len(body) < 1 {
+		return neighbors{}, fmt.Errorf("discover: malformed NEIGHBORS body")
+	}
+	count := int(body[0])
+	rest := body[1:]
+
+	entries := make([]neighborEntry, 0, count)
+	for i := 0; i < count; i++ {
+		var e neighborEntry
+		if len(rest) < len(e.ID) {
+			return neighbors{}, fmt.Errorf("discover: truncated NEIGHBORS entry")
+		}
+		copy(e.ID[:], rest[:len(e.ID)])
+		rest = rest[len(e.ID):]
+
+		var err error
+		if e.Addr, rest, err = takeString(rest); err != nil {
+			return neighbors{}, err
+		}
+		if e.Pub, rest, err = takeBytes(rest); err != nil {
+			return neighbors{}, err
+		}
+		entries = append(entries, e)
+	}
+	return neighbors{Entries: entries}, nil
+}
@@ -0,0 +1,90 @@
+package discover
+
+import (
+	"net"
+	"sort"
+	"sync"
+)
+
+// bucketSize is the classic Kademlia k=16: how many live contacts a
+// single bucket remembers before it's full.
+const bucketSize = 16
+
+// Node is one contact the table knows about, identified by both its
+// DHT position and the address to reach it at.
+type Node struct {
+	ID   NodeID
+	Addr *net.UDPAddr
+	Pub  []byte // identity public key NodeID was derived from
+}
+
+// bucket holds up to bucketSize nodes at a given XOR-distance range from
+// self, ordered least-recently-seen first so a full bucket evicts the
+// contact that has gone longest without confirming it's still alive.
+type bucket struct {
+	nodes []Node
+}
+
+func (b *bucket) upsert(n Node) {
+	for i, existing := range b.nodes {
+		if existing.ID == n.ID {
+			// Seen again: move to the back (most-recently-seen).
+			b.nodes = append(b.nodes[:i], b.nodes[i+1:]...)
+			b.nodes = append(b.nodes, n)
+			return
+		}
+	}
+	if len(b.nodes) >= bucketSize {
+		// Bucket full: drop the least-recently-seen entry rather than
+		// refuse the new one outright, so a stale contact that has
+		// actually gone offline doesn't permanently block its slot.
+		b.nodes = b.nodes[1:]
+	}
+	b.nodes = append(b.nodes, n)
+}
+
+// Table is a Kademlia routing table keyed on self's NodeID: idBits
+// buckets, each holding up to bucketSize contacts at that bucket's
+// XOR-distance range.
+type Table struct {
+	self NodeID
+
+	mu      sync.Mutex
+	buckets [idBits]bucket
+}
+
+// NewTable creates an empty routing table for self.
+func NewTable(self NodeID) *Table {
+	return &Table{self: self}
+}
+
+// Insert records n as seen, placing it in the bucket for its distance
+// from self. A node equal to self is ignored.
+func (t *Table) Insert(n Node) {
+	i := bucketIndex(t.self, n.ID)
+	if i < 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buckets[i].upsert(n)
+}
+
+// Closest returns up to count nodes in the table ordered by increasing
+// XOR distance from target.
+func (t *Table) Closest(target NodeID, count int) []Node {
+	t.mu.Lock()
+	var all []Node
+	for i := range t.buckets {
+		all = append(all, t.buckets[i].nodes...)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return less(distance(all[i].ID, target), distance(all[j].ID, target))
+	})
+	if len(all) > count {
+		all = all[:count]
+	}
+	return all
+}
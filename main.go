@@ -1,34 +1,221 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
-	"sort"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+
+	"github.com/ElysicConstructor/golang-p2p/discover"
+	"github.com/ElysicConstructor/golang-p2p/nat"
+	"github.com/ElysicConstructor/golang-p2p/pubsub"
+	"github.com/ElysicConstructor/golang-p2p/session"
+	"github.com/ElysicConstructor/golang-p2p/transport"
+	"github.com/ElysicConstructor/golang-p2p/wire"
 )
 
 const defaultPort = 5555
 
+// defaultSTUNServer is used to discover our own reflexive address when
+// none is configured. Google's is free and widely reachable, which is
+// all a chat toy needs.
+const defaultSTUNServer = "stun.l.google.com:19302"
+
+// bootstrapSeeds lists additional peer addresses to join the DHT
+// through on startup, alongside whatever introducer address is
+// configured. Any peer that has ever run discover.Discovery can serve
+// as a seed — there is nothing special about an introducer here.
+var bootstrapSeeds []string
+
 // ---------------- PeerSet ----------------
+
+// peerConn is everything we know about one remote peer: its current
+// address (which may change as NAT bindings come and go) and, once the
+// handshake has completed, the Session used to authenticate and encrypt
+// traffic to and from it. Its fields are written from both the UDP
+// read-loop goroutine (as handshakes and CONNECT frames arrive) and the
+// nat.AwaitDirect background goroutine (as hole punching succeeds or
+// times out), so every access goes through mu.
+type peerConn struct {
+	addr *net.UDPAddr // set once at creation, never mutated
+
+	mu            sync.Mutex
+	sess          *session.Session
+	pending       *session.Handshake  // set while a handshake is in flight
+	expectPub     string              // raw pubkey bytes the introducer's roster claims for this addr, if any
+	viaIntroducer bool                // true once hole punching has timed out and we're relaying instead
+	bestTransport transport.Multiaddr // highest-priority transport this peer and we have in common, once known
+	tConn         transport.Conn      // dialed/accepted connection for bestTransport, once it isn't udp
+}
+
+func (pc *peerConn) session() *session.Session {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.sess
+}
+
+func (pc *peerConn) setSession(s *session.Session) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.sess = s
+}
+
+func (pc *peerConn) pendingHandshake() *session.Handshake {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.pending
+}
+
+func (pc *peerConn) setPendingHandshake(h *session.Handshake) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.pending = h
+}
+
+func (pc *peerConn) expectedPub() string {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.expectPub
+}
+
+// setExpectedPubIfEmpty records pub as the expected identity for this
+// peer the first time it's called; later calls (e.g. from a second
+// roster entry) leave the original claim in place.
+func (pc *peerConn) setExpectedPubIfEmpty(pub string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.expectPub == "" {
+		pc.expectPub = pub
+	}
+}
+
+func (pc *peerConn) isViaIntroducer() bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.viaIntroducer
+}
+
+func (pc *peerConn) setViaIntroducer(v bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.viaIntroducer = v
+}
+
+func (pc *peerConn) setBestTransport(m transport.Multiaddr) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.bestTransport = m
+}
+
+func (pc *peerConn) getBestTransport() transport.Multiaddr {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.bestTransport
+}
+
+func (pc *peerConn) transportConn() transport.Conn {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.tConn
+}
+
+// setTransportConnIfEmpty installs c as this peer's dialed/accepted
+// transport connection, unless one is already set - whichever side
+// dials first (or the first accepted connection) wins, rather than
+// juggling two connections to the same peer.
+func (pc *peerConn) setTransportConnIfEmpty(c transport.Conn) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.tConn != nil {
+		return false
+	}
+	pc.tConn = c
+	return true
+}
+
+// clearTransportConn drops c as the current connection, but only if it
+// is still the current one - a stale read-loop goroutine for an
+// already-replaced connection shouldn't clobber a newer one.
+func (pc *peerConn) clearTransportConn(c transport.Conn) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.tConn == c {
+		pc.tConn = nil
+	}
+}
+
+// toMultiaddrs is a small convenience for converting the plain []string
+// session.Hello carries into the transport.Multiaddr values Best wants.
+func toMultiaddrs(ss []string) []transport.Multiaddr {
+	out := make([]transport.Multiaddr, len(ss))
+	for i, s := range ss {
+		out[i] = transport.Multiaddr(s)
+	}
+	return out
+}
+
+// peerSet is shared the same way peerConn is — the read-loop and
+// nat.AwaitDirect goroutines both look up and create entries — so its
+// map is guarded by mu too.
 type peerSet struct {
-	addrs map[string]*net.UDPAddr
+	mu     sync.Mutex
+	byAddr map[string]*peerConn
 }
 
-func newPeerSet() *peerSet { return &peerSet{addrs: make(map[string]*net.UDPAddr)} }
+func newPeerSet() *peerSet { return &peerSet{byAddr: make(map[string]*peerConn)} }
+
 func (ps *peerSet) add(addr *net.UDPAddr) {
-	ps.addrs[addr.String()] = addr
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.byAddr[addr.String()]; !ok {
+		ps.byAddr[addr.String()] = &peerConn{addr: addr}
+	}
 }
-func (ps *peerSet) list() []*net.UDPAddr {
-	out := make([]*net.UDPAddr, 0, len(ps.addrs))
-	for _, a := range ps.addrs {
-		out = append(out, a)
+
+func (ps *peerSet) get(addr *net.UDPAddr) *peerConn {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.byAddr[addr.String()]
+}
+
+func (ps *peerSet) ensure(addr *net.UDPAddr) *peerConn {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if pc, ok := ps.byAddr[addr.String()]; ok {
+		return pc
 	}
-	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
-	return out
+	pc := &peerConn{addr: addr}
+	ps.byAddr[addr.String()] = pc
+	return pc
+}
+
+// ensureExpecting registers addr (if new) together with the pubkey the
+// introducer's roster claims belongs to it, so the handshake result can
+// be checked against that claim.
+func (ps *peerSet) ensureExpecting(addr *net.UDPAddr, expectPub string) *peerConn {
+	pc := ps.ensure(addr)
+	pc.setExpectedPubIfEmpty(expectPub)
+	return pc
+}
+
+// byIP finds the peer whose known (UDP) address has ip, so an incoming
+// tcp/quic connection — which arrives from whatever ephemeral port the
+// peer dialed out on, not the port it advertised — can still be matched
+// back to the peerConn it belongs to.
+func (ps *peerSet) byIP(ip net.IP) *peerConn {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for _, pc := range ps.byAddr {
+		if pc.addr.IP.Equal(ip) {
+			return pc
+		}
+	}
+	return nil
 }
 
 // ---------------- TUI Peer ----------------
@@ -37,12 +224,18 @@ func runPeerTUI(name, room, introducerAddr, listen string) error {
 	conn, _ := net.ListenUDP("udp", laddr)
 	defer conn.Close()
 
+	id, err := session.LoadIdentity(fmt.Sprintf(".golang-p2p-%s.key", name))
+	if err != nil {
+		return fmt.Errorf("load identity: %w", err)
+	}
+
 	app := tview.NewApplication()
 
 	// Sidebar (Räume)
 	sidebar := tview.NewList().ShowSecondaryText(false)
 	sidebar.SetBorder(true).SetTitle("Räume")
 	sidebar.AddItem(room, "", 0, nil)
+	sidebar.AddItem("Ich: "+id.Fingerprint(), "", 0, nil)
 
 	// Chatbox
 	chatBox := tview.NewTextView().SetDynamicColors(true).SetScrollable(true)
@@ -53,6 +246,285 @@ func runPeerTUI(name, room, introducerAddr, listen string) error {
 	input.SetBorder(true)
 	peers := newPeerSet()
 
+	// introAddr is optional: with a seed list configured, a peer can
+	// join entirely through the DHT and never talk to an introducer.
+	var introAddr *net.UDPAddr
+	if introducerAddr != "" {
+		introAddr, err = net.ResolveUDPAddr("udp", introducerAddr)
+		if err != nil {
+			return fmt.Errorf("resolve introducer: %w", err)
+		}
+	}
+
+	// sendToAddr delivers a frame to a peer, transparently relaying it
+	// through the introducer if direct hole punching to that peer timed
+	// out (see the CodeConnect handler below). Once a Session exists for
+	// addr, the frame is sealed under it first: this is hop-by-hop, not
+	// end-to-end, encryption, which is what the gossip mesh needs — a
+	// forwarded chat message is re-sealed under each hop's own Session
+	// as it moves on, the same way the link itself is secured. Frames
+	// that establish that Session (the handshake itself) necessarily go
+	// out before pc.sess is set, so they're sent in the clear. Once a
+	// non-udp transport connection has been dialed for this peer (see
+	// dialBestTransport), that connection carries the frame instead of
+	// the raw UDP socket.
+	sendToAddr := func(addr *net.UDPAddr, frame []byte) {
+		pc := peers.get(addr)
+		if pc != nil {
+			if sess := pc.session(); sess != nil {
+				frame = wire.Encode(wire.CodeSealed, 0, sess.Seal(frame))
+			}
+			if tc := pc.transportConn(); tc != nil {
+				if err := tc.Write(frame); err == nil {
+					return
+				}
+				tc.Close()
+				pc.clearTransportConn(tc) // write failed: fall back to udp below
+			}
+		}
+		if pc != nil && pc.isViaIntroducer() {
+			env := nat.RelayEnvelope{Target: addr.String(), Inner: frame}
+			conn.WriteToUDP(wire.Encode(nat.CodeRelay, 0, env.Encode()), introAddr)
+			return
+		}
+		conn.WriteToUDP(frame, addr)
+	}
+
+	// dispatchFromPeer unwraps a CodeSealed frame under the sender's
+	// Session before handing the plaintext inner frame to wire.Dispatch,
+	// or dispatches it as-is if it isn't sealed (e.g. the handshake that
+	// establishes the Session in the first place). from identifies the
+	// peer for handler bookkeeping regardless of which socket (udp,
+	// dialed tcp/quic) the bytes actually arrived on.
+	dispatchFromPeer := func(from *net.UDPAddr, raw []byte) error {
+		f, err := wire.Decode(raw)
+		if err != nil {
+			return err
+		}
+		if f.Code != wire.CodeSealed {
+			return wire.Dispatch(from, raw)
+		}
+		pc := peers.get(from)
+		if pc == nil {
+			return fmt.Errorf("wire: sealed frame from unknown peer %s", from)
+		}
+		sess := pc.session()
+		if sess == nil {
+			return fmt.Errorf("wire: sealed frame from %s with no session", from)
+		}
+		plain, err := sess.Open(f.Body)
+		if err != nil {
+			return fmt.Errorf("wire: open sealed frame from %s: %w", from, err)
+		}
+		return wire.Dispatch(from, plain)
+	}
+
+	// readTransportConn pumps messages off a dialed or accepted tcp/quic
+	// Conn for pc until it errors (peer gone, connection reset), at
+	// which point it drops back to udp for that peer.
+	readTransportConn := func(pc *peerConn, c transport.Conn) {
+		defer pc.clearTransportConn(c)
+		defer c.Close()
+		for {
+			msg, err := c.Read()
+			if err != nil {
+				return
+			}
+			if err := dispatchFromPeer(pc.addr, msg); err != nil {
+				fmt.Println("wire:", err)
+			}
+		}
+	}
+
+	// dialBestTransport dials pc.bestTransport once it's known to be
+	// something other than udp, so this peer's traffic moves off the
+	// shared UDP socket and onto its own tcp/quic connection. Best is
+	// advisory, not authoritative — dialing is best-effort, and
+	// sendToAddr falls back to udp if it fails or the peer isn't
+	// reachable that way (e.g. behind a NAT that only hole punching
+	// solved for udp).
+	dialBestTransport := func(pc *peerConn) {
+		best := pc.getBestTransport()
+		if best == "" || best.Proto() == "udp" || pc.transportConn() != nil {
+			return
+		}
+		t, err := transport.ByName(best.Proto())
+		if err != nil {
+			return
+		}
+		hostPort, err := best.HostPort()
+		if err != nil {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			c, err := t.Dial(ctx, hostPort)
+			if err != nil {
+				return
+			}
+			if !pc.setTransportConnIfEmpty(c) {
+				c.Close()
+				return
+			}
+			go readTransportConn(pc, c)
+		}()
+	}
+
+	// router carries chat messages over the gossip mesh instead of one
+	// WriteToUDP per known peer: each message is forwarded by mesh
+	// neighbors rather than sent directly to everyone.
+	router := pubsub.NewRouter(id.Priv, sendToAddr, func(msg pubsub.Message) {
+		chatBox.Write([]byte(fmt.Sprintf("[green]%s\n", msg.Payload)))
+		app.Draw()
+	})
+
+	// myTransports holds the multiaddrs we advertise in our HELLO,
+	// filled in below once STUN has told us our reflexive address.
+	var myTransports []string
+
+	// startHandshake sends our HELLO to a newly discovered peer so both
+	// sides end up with a Session, used to verify the identity shown
+	// next to them in the sidebar. It is independent of gossip mesh
+	// membership, which only needs an address to forward frames to.
+	startHandshake := func(pc *peerConn) {
+		hs, err := session.NewHandshake(id, myTransports)
+		if err != nil {
+			return
+		}
+		pc.setPendingHandshake(hs)
+		frame := wire.Encode(wire.CodeHandshake, 0, session.EncodeHello(hs.Hello()))
+		sendToAddr(pc.addr, frame)
+	}
+
+	// Learn our own public address via STUN, and try to have the local
+	// router forward the listen port via UPnP so peers behind other
+	// NATs may not even need to punch. Both are best-effort: a chat
+	// session works without either, just less reliably.
+	reflexive := listen
+	if addr, err := nat.Reflexive(conn, defaultSTUNServer, 2*time.Second); err == nil {
+		reflexive = addr.String()
+	} else {
+		fmt.Println("nat: stun discovery failed:", err)
+	}
+	if host, port, err := net.SplitHostPort(reflexive); err == nil {
+		if p, err := strconv.Atoi(port); err == nil {
+			if addr, err := transport.New("udp", host, p); err == nil {
+				myTransports = append(myTransports, string(addr))
+			}
+		}
+	}
+	if err := nat.MapPort(laddr.Port, laddr.Port, "golang-p2p"); err != nil {
+		fmt.Println("nat: upnp mapping failed:", err)
+	}
+
+	// Listen on tcp and quic too, alongside the raw udp socket, so
+	// dialBestTransport has something better than udp to pick when both
+	// sides support it. tcp shares the same numeric port as udp - a
+	// different protocol, no conflict - but quic runs over its own UDP
+	// socket and can't reuse the port our raw udp conn already owns, so
+	// it gets laddr.Port+1. Both are best-effort: udp is all a chat
+	// session strictly needs.
+	acceptLoop := func(ln transport.Listener) {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			host, _, err := net.SplitHostPort(c.RemoteAddr().String())
+			if err != nil {
+				c.Close()
+				continue
+			}
+			pc := peers.byIP(net.ParseIP(host))
+			if pc == nil || !pc.setTransportConnIfEmpty(c) {
+				c.Close()
+				continue
+			}
+			go readTransportConn(pc, c)
+		}
+	}
+	// tcp/quic multiaddrs are advertised at our local listen address, not
+	// the STUN-discovered reflexive one: STUN only confirms the external
+	// mapping for the udp socket it probed, and a NAT is free to map the
+	// tcp and quic sockets' external ports completely differently (or
+	// not at all). Advertising the reflexive host here would tell a
+	// remote peer to dial a port we never actually verified is
+	// reachable, and dialBestTransport has no way to know it guessed
+	// wrong until the dial times out. Until there's a way to learn each
+	// socket's own reflexive mapping, tcp/quic are only offered for
+	// direct/LAN peers; udp (punched above) remains the one transport we
+	// know gets through a NAT.
+	if host, _, err := net.SplitHostPort(listen); err == nil {
+		if tcpLn, err := transport.TCP.Listen(fmt.Sprintf(":%d", laddr.Port)); err == nil {
+			go acceptLoop(tcpLn)
+			if addr, err := transport.New("tcp", host, laddr.Port); err == nil {
+				myTransports = append(myTransports, string(addr))
+			}
+		} else {
+			fmt.Println("transport: tcp listen failed:", err)
+		}
+		quicPort := laddr.Port + 1
+		if quicLn, err := transport.QUIC.Listen(fmt.Sprintf(":%d", quicPort)); err == nil {
+			go acceptLoop(quicLn)
+			if addr, err := transport.New("quic", host, quicPort); err == nil {
+				myTransports = append(myTransports, string(addr))
+			}
+		} else {
+			fmt.Println("transport: quic listen failed:", err)
+		}
+	}
+
+	// addRosterPeer registers a peer vouched for by either the
+	// introducer or the DHT: it joins the gossip mesh immediately, and
+	// a handshake starts alongside it so we can show a verified
+	// fingerprint for it.
+	addRosterPeer := func(entry wire.PeerListEntry) {
+		addr, err := net.ResolveUDPAddr("udp", entry.Addr)
+		if err != nil {
+			return
+		}
+		router.AddPeer(addr)
+		pc := peers.ensureExpecting(addr, string(entry.Pub))
+		if pc.pendingHandshake() == nil && pc.session() == nil {
+			startHandshake(pc)
+		}
+	}
+
+	selfAddr := laddr
+	if addr, err := net.ResolveUDPAddr("udp", reflexive); err == nil {
+		selfAddr = addr
+	}
+	disco := discover.NewDiscovery(discover.Node{ID: discover.IDFromPubKey(id.Pub), Addr: selfAddr, Pub: id.Pub}, sendToAddr)
+
+	seeds := append([]string(nil), bootstrapSeeds...)
+	if introducerAddr != "" {
+		seeds = append(seeds, introducerAddr)
+	}
+	if len(seeds) > 0 {
+		// Bootstrapping and the room lookup both take a round trip per
+		// hop, so they run off the UI goroutine rather than blocking
+		// startup.
+		go func() {
+			if err := disco.Bootstrap(seeds); err != nil {
+				fmt.Println("discover: bootstrap failed:", err)
+				return
+			}
+			for _, n := range disco.Rendezvous(room) {
+				addRosterPeer(wire.PeerListEntry{Addr: n.Addr.String(), Pub: n.Pub})
+			}
+		}()
+	}
+
+	// Announce ourselves to the introducer, if one is configured, so it
+	// can hand out our address and public key to the rest of the
+	// room's roster. With a DHT bootstrapped above, this is now just
+	// one of two ways to find the initial peer set, not the only one.
+	if introAddr != nil {
+		join := wire.Join{Room: room, Name: name, Pub: id.Pub, ReflexiveAddr: reflexive}
+		conn.WriteToUDP(wire.Encode(wire.CodeJoin, 0, join.Encode()), introAddr)
+	}
+
 	input.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEnter {
 			msg := input.GetText()
@@ -60,9 +532,7 @@ func runPeerTUI(name, room, introducerAddr, listen string) error {
 				line := fmt.Sprintf("%s: %s", name, msg)
 				chatBox.Write([]byte(fmt.Sprintf("[yellow]%s\n", line)))
 				input.SetText("")
-				for _, p := range peers.list() {
-					conn.WriteToUDP([]byte("MSG "+line), p)
-				}
+				router.Publish([]byte(line))
 			}
 		}
 	})
@@ -78,18 +548,92 @@ func runPeerTUI(name, room, introducerAddr, listen string) error {
 
 	app.SetRoot(grid, true).SetFocus(input)
 
+	wire.RegisterCodec(wire.CodeHandshake, func(from *net.UDPAddr, body []byte) {
+		peerHello, err := session.DecodeHello(body)
+		if err != nil {
+			return
+		}
+		pc := peers.ensure(from)
+		if pc.pendingHandshake() == nil {
+			startHandshake(pc)
+		}
+		sess, err := pc.pendingHandshake().Finish(peerHello)
+		if err != nil {
+			return
+		}
+		if expect := pc.expectedPub(); expect != "" && expect != string(sess.PeerKey) {
+			return // introducer's roster and the handshake disagree on who this is
+		}
+		pc.setSession(sess)
+		if best, err := transport.Best(toMultiaddrs(myTransports), toMultiaddrs(peerHello.Transports)); err == nil {
+			pc.setBestTransport(best)
+			dialBestTransport(pc)
+		}
+		sidebar.AddItem(from.String()+" "+session.Fingerprint(sess.PeerKey), "", 0, nil)
+		app.Draw()
+	})
+
+	wire.RegisterCodec(wire.CodePeerList, func(from *net.UDPAddr, body []byte) {
+		list, err := wire.DecodePeerList(body)
+		if err != nil {
+			return
+		}
+		for _, entry := range list.Entries {
+			addRosterPeer(entry)
+		}
+	})
+
+	wire.RegisterCodec(wire.CodePunch, func(from *net.UDPAddr, body []byte) {
+		peers.add(from)
+		router.AddPeer(from)
+		pc := peers.get(from)
+		// nat.Punch fires up to 10 datagrams per punch episode, and a
+		// stray PUNCH can still arrive after a session is established;
+		// only start a handshake if one isn't already in flight or done,
+		// same guard as the roster path. Restarting resets sendSeq to 0
+		// against a session whose peer already has a high recvLo, so its
+		// Open rejects the new traffic as replayed and the channel
+		// stalls - and since d2e8ded, sendToAddr would seal the new HELLO
+		// under the old (about-to-be-replaced) session's keys.
+		if pc.pendingHandshake() == nil && pc.session() == nil {
+			startHandshake(pc)
+		}
+	})
+
+	// CodeConnect is the introducer telling us to simultaneously-open a
+	// NAT binding to a peer we haven't heard from directly yet.
+	wire.RegisterCodec(nat.CodeConnect, func(from *net.UDPAddr, body []byte) {
+		connect, err := nat.DecodeConnect(body)
+		if err != nil {
+			return
+		}
+		target, err := net.ResolveUDPAddr("udp", connect.PeerAddr)
+		if err != nil {
+			return
+		}
+		pc := peers.ensure(target)
+
+		nat.Punch(func(addr *net.UDPAddr) {
+			conn.WriteToUDP(wire.Encode(wire.CodePunch, 0, nil), addr)
+		}, target)
+
+		nat.AwaitDirect(func() bool {
+			return pc.session() != nil
+		}, func() {
+			pc.setViaIntroducer(true)
+		})
+	})
+
 	// Empfange Nachrichten
 	go func() {
 		buf := make([]byte, 4096)
 		for {
-			n, from, _ := conn.ReadFromUDP(buf)
-			line := strings.TrimSpace(string(buf[:n]))
-			if strings.HasPrefix(line, "MSG ") {
-				line = strings.TrimPrefix(line, "MSG ")
-				chatBox.Write([]byte(fmt.Sprintf("[green]%s\n", line)))
-				app.Draw()
-			} else if strings.HasPrefix(line, "PUNCH") {
-				peers.add(from)
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				continue
+			}
+			if err := dispatchFromPeer(from, buf[:n]); err != nil {
+				fmt.Println("wire:", err)
 			}
 		}
 	}()
@@ -98,11 +642,29 @@ func runPeerTUI(name, room, introducerAddr, listen string) error {
 }
 
 // ---------------- Introducer ----------------
+
+// roomMember is one entry in the introducer's roster: an address plus
+// the identity it claims to own. Peers trust this pairing implicitly by
+// virtue of trusting the introducer, so a room's security is only as
+// good as the introducer distributing it.
+type roomMember struct {
+	addr      *net.UDPAddr
+	pub       []byte
+	reflexive string // STUN-discovered public address, used for CONNECT coordination
+}
+
+// roomState is a lightweight roster, not a source of truth the room
+// depends on at runtime: once peers have bootstrapped their gossip mesh
+// from it, the introducer is never consulted again for that room.
 type roomState struct {
-	peers map[string]*net.UDPAddr
+	peers map[string]roomMember
 }
 
+// introducer's rooms map (and each roomState.peers) is mutated by
+// handleJoin/handleLeave, which run concurrently - one goroutine per
+// received datagram - so both are guarded by mu.
 type introducer struct {
+	mu    sync.Mutex
 	rooms map[string]*roomState
 	conn  *net.UDPConn
 }
@@ -115,47 +677,104 @@ func runIntroducer(listen string) error {
 	intr := &introducer{rooms: make(map[string]*roomState), conn: conn}
 	fmt.Println("Introducer läuft auf", conn.LocalAddr())
 
+	wire.RegisterCodec(wire.CodeJoin, intr.handleJoin)
+	wire.RegisterCodec(wire.CodeLeave, intr.handleLeave)
+	nat.RegisterRelay(func(addr *net.UDPAddr, frame []byte) {
+		conn.WriteToUDP(frame, addr)
+	})
+
+	// The introducer is otherwise an ordinary DHT node at a well-known
+	// address: it answers PING/FIND_NODE like any peer, which is what
+	// lets other peers use it as just one seed among bootstrapSeeds
+	// instead of a protocol of its own.
+	id, err := session.GenIdentity()
+	if err != nil {
+		return fmt.Errorf("generate introducer identity: %w", err)
+	}
+	discover.NewDiscovery(discover.Node{ID: discover.IDFromPubKey(id.Pub), Addr: addr, Pub: id.Pub}, func(to *net.UDPAddr, frame []byte) {
+		conn.WriteToUDP(frame, to)
+	})
+
 	buf := make([]byte, 2048)
 	for {
-		n, from, _ := conn.ReadFromUDP(buf)
-		msg := strings.TrimSpace(string(buf[:n]))
-		go intr.handle(from, msg)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			continue
+		}
+		msg := append([]byte(nil), buf[:n]...)
+		go func(from *net.UDPAddr, msg []byte) {
+			if err := wire.Dispatch(from, msg); err != nil {
+				fmt.Println("wire:", err)
+			}
+		}(from, msg)
 	}
 }
 
-func (in *introducer) handle(from *net.UDPAddr, msg string) {
-	parts := strings.Fields(msg)
-	if len(parts) < 2 {
+func (in *introducer) handleJoin(from *net.UDPAddr, body []byte) {
+	j, err := wire.DecodeJoin(body)
+	if err != nil {
 		return
 	}
-	cmd := strings.ToUpper(parts[0])
-	switch cmd {
-	case "JOIN":
-		if len(parts) < 3 {
-			return
-		}
-		room := parts[1]
-		name := parts[2]
-		rs := in.rooms[room]
-		if rs == nil {
-			rs = &roomState{peers: make(map[string]*net.UDPAddr)}
-			in.rooms[room] = rs
-		}
-		rs.peers[from.String()] = from
-		fmt.Println("Peer beigetreten:", name, from.String())
-	case "LEAVE":
-		room := parts[1]
-		if rs, ok := in.rooms[room]; ok {
-			delete(rs.peers, from.String())
-			if len(rs.peers) == 0 {
-				delete(in.rooms, room)
-			}
+
+	// Snapshot who was already in the room and record the newcomer under
+	// mu, then reply from the snapshot once unlocked - two concurrent
+	// JOINs (the common case while a room is forming) would otherwise be
+	// concurrent map reads/writes on the same roomState.peers.
+	in.mu.Lock()
+	rs := in.rooms[j.Room]
+	if rs == nil {
+		rs = &roomState{peers: make(map[string]roomMember)}
+		in.rooms[j.Room] = rs
+	}
+	existing := make([]roomMember, 0, len(rs.peers))
+	for _, m := range rs.peers {
+		existing = append(existing, m)
+	}
+	rs.peers[from.String()] = roomMember{addr: from, pub: j.Pub, reflexive: j.ReflexiveAddr}
+	in.mu.Unlock()
+
+	// Tell the newcomer about everyone already in the room...
+	roster := wire.PeerList{}
+	for _, m := range existing {
+		roster.Entries = append(roster.Entries, wire.PeerListEntry{Addr: m.addr.String(), Pub: m.pub})
+	}
+	in.reply(from, wire.CodePeerList, roster.Encode())
+
+	// ...and tell everyone already there about the newcomer, so both
+	// sides end up initiating a handshake.
+	newcomer := wire.PeerList{Entries: []wire.PeerListEntry{{Addr: from.String(), Pub: j.Pub}}}
+	for _, m := range existing {
+		in.reply(m.addr, wire.CodePeerList, newcomer.Encode())
+	}
+
+	// Coordinate simultaneous open: tell the newcomer and each existing
+	// member about each other's reflexive address so both sides punch
+	// at (about) the same time.
+	for _, m := range existing {
+		in.reply(from, nat.CodeConnect, nat.Connect{PeerAddr: m.reflexive}.Encode())
+		in.reply(m.addr, nat.CodeConnect, nat.Connect{PeerAddr: j.ReflexiveAddr}.Encode())
+	}
+
+	fmt.Println("Peer beigetreten:", j.Name, from.String())
+}
+
+func (in *introducer) handleLeave(from *net.UDPAddr, body []byte) {
+	l, err := wire.DecodeLeave(body)
+	if err != nil {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if rs, ok := in.rooms[l.Room]; ok {
+		delete(rs.peers, from.String())
+		if len(rs.peers) == 0 {
+			delete(in.rooms, l.Room)
 		}
 	}
 }
 
-func (in *introducer) reply(to *net.UDPAddr, msg string) {
-	_, _ = in.conn.WriteToUDP([]byte(msg), to)
+func (in *introducer) reply(to *net.UDPAddr, code wire.Code, body []byte) {
+	_, _ = in.conn.WriteToUDP(wire.Encode(code, 0, body), to)
 }
 
 // ---------------- Auto-Start ----------------
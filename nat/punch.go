@@ -0,0 +1,55 @@
+package nat
+
+import (
+	"net"
+	"time"
+)
+
+// punchCount and punchWindow match the "fire N packets over ~2s"
+// simultaneous-open approach: enough packets, spread widely enough,
+// that at least one from each side crosses the NATs while both
+// bindings are open.
+const (
+	punchCount  = 10
+	punchWindow = 2 * time.Second
+)
+
+// Punch fires punchCount datagrams at target, spaced evenly across
+// punchWindow. send is expected to write a PUNCH wire frame — Punch
+// itself is transport-agnostic, it only drives the timing.
+func Punch(send func(target *net.UDPAddr), target *net.UDPAddr) {
+	go func() {
+		interval := punchWindow / punchCount
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for i := 0; i < punchCount; i++ {
+			send(target)
+			<-ticker.C
+		}
+	}()
+}
+
+// fallbackTimeout is how long we give direct hole punching to succeed
+// before assuming it failed and relaying through the introducer
+// instead.
+const fallbackTimeout = punchWindow + 500*time.Millisecond
+
+// AwaitDirect polls established (which should report whether a direct,
+// authenticated session with the punch target now exists) and calls
+// onFallback once if it still reports false after fallbackTimeout.
+func AwaitDirect(established func() bool, onFallback func()) {
+	go func() {
+		deadline := time.Now().Add(fallbackTimeout)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for time.Now().Before(deadline) {
+			if established() {
+				return
+			}
+			<-ticker.C
+		}
+		if !established() {
+			onFallback()
+		}
+	}()
+}
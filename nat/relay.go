@@ -0,0 +1,82 @@
+package nat
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ElysicConstructor/golang-p2p/wire"
+)
+
+// CodeConnect and CodeRelay are nat-specific wire codes, defined
+// outside wire's core enum the same way pubsub reserves its own range —
+// see wire.RegisterCodec.
+const (
+	CodeConnect wire.Code = 0x2000 + iota
+	CodeRelay
+)
+
+// Connect is sent by the introducer to both sides of a new pairing so
+// they punch towards each other's reflexive address at (about) the
+// same time.
+type Connect struct {
+	PeerAddr string // the other peer's reflexive ip:port
+}
+
+func (c Connect) Encode() []byte {
+	return []byte(c.PeerAddr)
+}
+
+func DecodeConnect(body []byte) (Connect, error) {
+	return Connect{PeerAddr: string(body)}, nil
+}
+
+// RelayEnvelope wraps an already-encoded wire frame addressed to
+// Target, so the introducer can forward it unopened when direct
+// punching to Target failed. The introducer is the only peer expected
+// to ever see a RelayEnvelope.
+type RelayEnvelope struct {
+	Target string
+	Inner  []byte
+}
+
+func (e RelayEnvelope) Encode() []byte {
+	out := make([]byte, 0, 2+len(e.Target)+len(e.Inner))
+	if len(e.Target) > 255 {
+		e.Target = e.Target[:255]
+	}
+	out = append(out, byte(len(e.Target)))
+	out = append(out, []byte(e.Target)...)
+	out = append(out, e.Inner...)
+	return out
+}
+
+func DecodeRelay(body []byte) (RelayEnvelope, error) {
+	if len(body) < 1 {
+		return RelayEnvelope{}, fmt.Errorf("nat: relay envelope too short")
+	}
+	targetLen := int(body[0])
+	if len(body) < 1+targetLen {
+		return RelayEnvelope{}, fmt.Errorf("nat: relay envelope truncated")
+	}
+	return RelayEnvelope{
+		Target: string(body[1 : 1+targetLen]),
+		Inner:  body[1+targetLen:],
+	}, nil
+}
+
+// RegisterRelay wires a CodeRelay handler that forwards Inner verbatim
+// to Target, resolved with net.ResolveUDPAddr. Call this once, from the
+// introducer only — peers never relay on each other's behalf.
+func RegisterRelay(send func(addr *net.UDPAddr, frame []byte)) {
+	wire.RegisterCodec(CodeRelay, func(from *net.UDPAddr, body []byte) {
+		env, err := DecodeRelay(body)
+		if err != nil {
+			return
+		}
+		target, err := net.ResolveUDPAddr("udp", env.Target)
+		if err != nil {
+			return
+		}
+		send(target, env.Inner)
+	})
+}
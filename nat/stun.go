@@ -0,0 +1,148 @@
+// Package nat implements the traversal techniques needed to connect two
+// peers that both sit behind NATs: learning a peer's public address via
+// STUN, opening a NAT binding for it via simultaneous UDP hole
+// punching, falling back to relaying through the introducer if that
+// fails, and (optionally) asking the local router to forward a port via
+// UPnP-IGD so punching isn't needed at all.
+package nat
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// stunMagicCookie is fixed by RFC 5389 and lets us tell a STUN response
+// apart from garbage on the same socket.
+const stunMagicCookie uint32 = 0x2112A442
+
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingResponse = 0x0101
+
+	attrXorMappedAddress = 0x0020
+	attrMappedAddress    = 0x0001
+
+	familyIPv4 = 0x01
+	familyIPv6 = 0x02
+)
+
+// Reflexive performs a STUN Binding Request against server over conn
+// and returns the public "ip:port" the server observed the request
+// coming from — i.e. the reflexive address a NAT has mapped this
+// socket's outbound traffic to.
+func Reflexive(conn *net.UDPConn, server string, timeout time.Duration) (*net.UDPAddr, error) {
+	serverAddr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, fmt.Errorf("nat: resolve stun server: %w", err)
+	}
+
+	var txID [12]byte
+	if _, err := rand.Read(txID[:]); err != nil {
+		return nil, fmt.Errorf("nat: transaction id: %w", err)
+	}
+	req := encodeStunHeader(stunBindingRequest, txID, 0)
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, fmt.Errorf("nat: set deadline: %w", err)
+	}
+	defer conn.SetDeadline(time.Time{})
+
+	if _, err := conn.WriteToUDP(req, serverAddr); err != nil {
+		return nil, fmt.Errorf("nat: send binding request: %w", err)
+	}
+
+	buf := make([]byte, 512)
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		return nil, fmt.Errorf("nat: read binding response: %w", err)
+	}
+
+	return decodeBindingResponse(buf[:n], txID)
+}
+
+func encodeStunHeader(msgType uint16, txID [12]byte, bodyLen uint16) []byte {
+	out := make([]byte, 20)
+	binary.BigEndian.PutUint16(out[0:2], msgType)
+	binary.BigEndian.PutUint16(out[2:4], bodyLen)
+	binary.BigEndian.PutUint32(out[4:8], stunMagicCookie)
+	copy(out[8:20], txID[:])
+	return out
+}
+
+func decodeBindingResponse(data []byte, wantTxID [12]byte) (*net.UDPAddr, error) {
+	if len(data) < 20 {
+		return nil, fmt.Errorf("nat: stun response too short")
+	}
+	msgType := binary.BigEndian.Uint16(data[0:2])
+	if msgType != stunBindingResponse {
+		return nil, fmt.Errorf("nat: unexpected stun message type %#x", msgType)
+	}
+	if binary.BigEndian.Uint32(data[4:8]) != stunMagicCookie {
+		return nil, fmt.Errorf("nat: bad stun magic cookie")
+	}
+	var gotTxID [12]byte
+	copy(gotTxID[:], data[8:20])
+	if gotTxID != wantTxID {
+		return nil, fmt.Errorf("nat: stun transaction id mismatch")
+	}
+
+	attrs := data[20:]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := binary.BigEndian.Uint16(attrs[2:4])
+		if int(attrLen)+4 > len(attrs) {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case attrXorMappedAddress:
+			if addr, err := parseXorMappedAddress(value); err == nil {
+				return addr, nil
+			}
+		case attrMappedAddress:
+			if addr, err := parseMappedAddress(value); err == nil {
+				return addr, nil
+			}
+		}
+
+		// Attributes are padded to a 4-byte boundary.
+		advance := 4 + int(attrLen)
+		if pad := advance % 4; pad != 0 {
+			advance += 4 - pad
+		}
+		if advance > len(attrs) {
+			break
+		}
+		attrs = attrs[advance:]
+	}
+	return nil, fmt.Errorf("nat: stun response had no mapped address")
+}
+
+func parseMappedAddress(value []byte) (*net.UDPAddr, error) {
+	if len(value) < 8 || value[1] != familyIPv4 {
+		return nil, fmt.Errorf("nat: unsupported mapped address")
+	}
+	port := binary.BigEndian.Uint16(value[2:4])
+	ip := net.IP(value[4:8])
+	return &net.UDPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func parseXorMappedAddress(value []byte) (*net.UDPAddr, error) {
+	if len(value) < 8 || value[1] != familyIPv4 {
+		return nil, fmt.Errorf("nat: unsupported xor-mapped address")
+	}
+	port := binary.BigEndian.Uint16(value[2:4]) ^ uint16(stunMagicCookie>>16)
+
+	var ipBytes [4]byte
+	copy(ipBytes[:], value[4:8])
+	var cookie [4]byte
+	binary.BigEndian.PutUint32(cookie[:], stunMagicCookie)
+	for i := range ipBytes {
+		ipBytes[i] ^= cookie[i]
+	}
+	return &net.UDPAddr{IP: net.IP(ipBytes[:]), Port: int(port)}, nil
+}
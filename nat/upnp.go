@@ -0,0 +1,197 @@
+package nat
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ssdpDiscoverTimeout bounds how long we wait for a router to answer
+// the multicast M-SEARCH before giving up on UPnP entirely.
+const ssdpDiscoverTimeout = 2 * time.Second
+
+// wanIPConnection is the UPnP service type tendermint's upnp helper
+// and most consumer routers implement for port forwarding.
+const wanIPConnection = "urn:schemas-upnp-org:service:WANIPConnection:1"
+
+// MapPort asks the local network's UPnP-IGD router (if any) to forward
+// external port -> this host's internal port over UDP. It is entirely
+// best-effort: any failure (no router, no UPnP, CGNAT, ...) is returned
+// as a plain error for the caller to log and ignore.
+func MapPort(externalPort, internalPort int, description string) error {
+	loc, err := discoverIGD()
+	if err != nil {
+		return fmt.Errorf("nat: upnp discovery: %w", err)
+	}
+	ctrlURL, err := fetchControlURL(loc)
+	if err != nil {
+		return fmt.Errorf("nat: upnp control url: %w", err)
+	}
+	localIP, err := outboundIP()
+	if err != nil {
+		return fmt.Errorf("nat: determine local ip: %w", err)
+	}
+	return addPortMapping(ctrlURL, externalPort, internalPort, localIP, description)
+}
+
+// discoverIGD multicasts an SSDP M-SEARCH and returns the device
+// description URL from the first WANIPConnection responder.
+func discoverIGD() (string, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	mcast := &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+	req := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n" +
+		"ST: " + wanIPConnection + "\r\n\r\n"
+
+	if _, err := conn.WriteTo([]byte(req), mcast); err != nil {
+		return "", err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(ssdpDiscoverTimeout)); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 2048)
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		return "", fmt.Errorf("no IGD responded: %w", err)
+	}
+
+	for _, line := range strings.Split(string(buf[:n]), "\r\n") {
+		if strings.HasPrefix(strings.ToUpper(line), "LOCATION:") {
+			return strings.TrimSpace(line[len("LOCATION:"):]), nil
+		}
+	}
+	return "", fmt.Errorf("ssdp response had no LOCATION header")
+}
+
+// deviceDescription is the small slice of a UPnP device description
+// document we actually need: the control URL for WANIPConnection.
+type deviceDescription struct {
+	XMLName xml.Name `xml:"root"`
+	Device  struct {
+		ServiceList struct {
+			Service []struct {
+				ServiceType string `xml:"serviceType"`
+				ControlURL  string `xml:"controlURL"`
+			} `xml:"service"`
+		} `xml:"serviceList"`
+		DeviceList struct {
+			Device []struct {
+				ServiceList struct {
+					Service []struct {
+						ServiceType string `xml:"serviceType"`
+						ControlURL  string `xml:"controlURL"`
+					} `xml:"service"`
+				} `xml:"serviceList"`
+			} `xml:"device"`
+		} `xml:"deviceList"`
+	} `xml:"device"`
+}
+
+func fetchControlURL(locationURL string) (string, error) {
+	resp, err := http.Get(locationURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var desc deviceDescription
+	if err := xml.Unmarshal(body, &desc); err != nil {
+		return "", err
+	}
+
+	for _, s := range desc.Device.ServiceList.Service {
+		if s.ServiceType == wanIPConnection {
+			return resolveAgainst(locationURL, s.ControlURL), nil
+		}
+	}
+	for _, d := range desc.Device.DeviceList.Device {
+		for _, s := range d.ServiceList.Service {
+			if s.ServiceType == wanIPConnection {
+				return resolveAgainst(locationURL, s.ControlURL), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no %s service in device description", wanIPConnection)
+}
+
+func resolveAgainst(base, ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return ref
+	}
+	schemeEnd := strings.Index(base, "://")
+	if schemeEnd < 0 {
+		return ref
+	}
+	hostEnd := strings.Index(base[schemeEnd+3:], "/")
+	if hostEnd < 0 {
+		return base + ref
+	}
+	return base[:schemeEnd+3+hostEnd] + ref
+}
+
+func addPortMapping(ctrlURL string, externalPort, internalPort int, internalIP, description string) error {
+	soapBody := fmt.Sprintf(`<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+  <s:Body>
+    <u:AddPortMapping xmlns:u="%s">
+      <NewRemoteHost></NewRemoteHost>
+      <NewExternalPort>%d</NewExternalPort>
+      <NewProtocol>UDP</NewProtocol>
+      <NewInternalPort>%d</NewInternalPort>
+      <NewInternalClient>%s</NewInternalClient>
+      <NewEnabled>1</NewEnabled>
+      <NewPortMappingDescription>%s</NewPortMappingDescription>
+      <NewLeaseDuration>0</NewLeaseDuration>
+    </u:AddPortMapping>
+  </s:Body>
+</s:Envelope>`, wanIPConnection, externalPort, internalPort, internalIP, description)
+
+	req, err := http.NewRequest(http.MethodPost, ctrlURL, bytes.NewBufferString(soapBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#AddPortMapping"`, wanIPConnection))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("AddPortMapping failed: %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// outboundIP finds the local address that would be used to reach the
+// public internet, which is what the router needs as the mapping's
+// internal client.
+func outboundIP() (string, error) {
+	conn, err := net.Dial("udp", "8.8.8.8:80")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
@@ -0,0 +1,40 @@
+package pubsub
+
+import "fmt"
+
+// encodeIDs and decodeIDs serialize the ID lists carried by IHAVE/IWANT
+// gossip frames: a count followed by that many 32-byte IDs.
+
+func encodeIDs(ids []ID) []byte {
+	out := make([]byte, 0, 8+len(ids)*32)
+	out = appendUint64(out, uint64(len(ids)))
+	for _, id := range ids {
+		out = append(out, id[:]...)
+	}
+	return out
+}
+
+func decodeIDs(data []byte) ([]ID, error) {
+	count, rest, err := takeUint64(data)
+	if err != nil {
+		return nil, err
+	}
+	// count comes straight off the wire: cap the allocation at what rest
+	// could actually hold (32 bytes/ID) instead of trusting it, so one
+	// crafted frame can't force a multi-GB allocation before the
+	// truncation check below ever runs.
+	if max := uint64(len(rest)) / 32; count > max {
+		return nil, fmt.Errorf("pubsub: id count %d exceeds data for %d bytes", count, len(rest))
+	}
+	ids := make([]ID, 0, count)
+	for i := uint64(0); i < count; i++ {
+		if len(rest) < 32 {
+			return nil, fmt.Errorf("pubsub: truncated id list")
+		}
+		var id ID
+		copy(id[:], rest[:32])
+		ids = append(ids, id)
+		rest = rest[32:]
+	}
+	return ids, nil
+}
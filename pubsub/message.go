@@ -0,0 +1,127 @@
+// Package pubsub implements a small gossipsub-style overlay for a chat
+// room: instead of every peer writing a datagram to every other peer
+// (O(n^2) sends, no delivery guarantees), each peer forwards a message
+// once to a handful of mesh neighbors, who forward it again, and so on.
+//
+// Because a forwarded message may pass through peers it has no pairwise
+// session with, messages are authenticated end to end with the sender's
+// signature rather than with the sender's session.Session. This package
+// does no encryption itself; any sealing is done per link by the send
+// function the Router is given, so each relay sees the plaintext message.
+package pubsub
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ID identifies a message for deduplication purposes.
+type ID [32]byte
+
+// Message is one gossiped chat line, signed by its original sender.
+type Message struct {
+	Sender  ed25519.PublicKey
+	Seqno   uint64
+	Payload []byte
+	Sig     []byte
+}
+
+// ID is blake2b(sender_pub || seqno), matching what every peer that
+// sees this message will compute, so duplicates are recognized
+// regardless of which neighbor relayed them.
+func (m Message) ID() ID {
+	buf := make([]byte, 0, len(m.Sender)+8)
+	buf = append(buf, m.Sender...)
+	buf = appendUint64(buf, m.Seqno)
+	return blake2b.Sum256(buf)
+}
+
+func (m Message) signedBytes() []byte {
+	buf := make([]byte, 0, 8+len(m.Payload))
+	buf = appendUint64(buf, m.Seqno)
+	return append(buf, m.Payload...)
+}
+
+// Sign builds a Message for payload, numbered seqno, signed by priv.
+func Sign(priv ed25519.PrivateKey, seqno uint64, payload []byte) Message {
+	m := Message{Sender: priv.Public().(ed25519.PublicKey), Seqno: seqno, Payload: payload}
+	m.Sig = ed25519.Sign(priv, m.signedBytes())
+	return m
+}
+
+// Verify reports whether Sig is a valid signature by Sender over this
+// message's seqno and payload.
+func (m Message) Verify() bool {
+	if len(m.Sender) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(m.Sender, m.signedBytes(), m.Sig)
+}
+
+// Encode serializes m for the wire.
+func (m Message) Encode() []byte {
+	out := make([]byte, 0, 4+len(m.Sender)+8+4+len(m.Payload)+4+len(m.Sig))
+	out = appendBytes(out, m.Sender)
+	out = appendUint64(out, m.Seqno)
+	out = appendBytes(out, m.Payload)
+	out = appendBytes(out, m.Sig)
+	return out
+}
+
+// Decode is the inverse of Encode.
+func Decode(data []byte) (Message, error) {
+	var m Message
+	sender, rest, err := takeBytes(data)
+	if err != nil {
+		return m, err
+	}
+	seqno, rest, err := takeUint64(rest)
+	if err != nil {
+		return m, err
+	}
+	payload, rest, err := takeBytes(rest)
+	if err != nil {
+		return m, err
+	}
+	sig, _, err := takeBytes(rest)
+	if err != nil {
+		return m, err
+	}
+	m.Sender = ed25519.PublicKey(sender)
+	m.Seqno = seqno
+	m.Payload = payload
+	m.Sig = sig
+	return m, nil
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendBytes(buf, field []byte) []byte {
+	buf = appendUint64(buf, uint64(len(field)))
+	return append(buf, field...)
+}
+
+func takeUint64(data []byte) (uint64, []byte, error) {
+	if len(data) < 8 {
+		return 0, nil, fmt.Errorf("pubsub: truncated uint64")
+	}
+	return binary.BigEndian.Uint64(data[:8]), data[8:], nil
+}
+
+func takeBytes(data []byte) ([]byte, []byte, error) {
+	length, rest, err := takeUint64(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint64(len(rest)) < length {
+		return nil, nil, fmt.Errorf("pubsub: truncated field (want %d, have %d)", length, len(rest))
+	}
+	return rest[:length], rest[length:], nil
+}
@@ -0,0 +1,270 @@
+package pubsub
+
+import (
+	"crypto/ed25519"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ElysicConstructor/golang-p2p/wire"
+)
+
+// meshSize is the target number of mesh neighbors each peer forwards
+// messages to eagerly, matching libp2p gossipsub's default D.
+const meshSize = 6
+
+// seenTTL bounds how long a message ID is remembered for
+// deduplication (and, while within the window, how long its full body
+// stays available to answer IWANT pulls).
+const seenTTL = 2 * time.Minute
+
+// gossipInterval is how often a peer tells its non-mesh room peers
+// which message IDs it has seen recently, so they can pull anything
+// they missed.
+const gossipInterval = 10 * time.Second
+
+// CodeIHave and CodeIWant are pubsub-specific wire codes. They live
+// outside wire's core enum (JOIN..SEALED) precisely so a subsystem
+// like this one can define its own message types via
+// wire.RegisterCodec without the core loop needing to know about them.
+const (
+	CodeIHave wire.Code = 0x1000 + iota
+	CodeIWant
+)
+
+// Router runs one room's gossip mesh: it tracks a subset of known room
+// peers as eager-forward neighbors, deduplicates messages by ID, and
+// periodically gossips IDs to the rest so the mesh self-heals as peers
+// come and go. It has no notion of a central relay — the introducer is
+// only ever consulted to learn the initial peer set.
+type Router struct {
+	priv ed25519.PrivateKey
+	send func(addr *net.UDPAddr, frame []byte)
+
+	onDeliver func(Message)
+
+	mu    sync.Mutex
+	seq   uint64
+	mesh  map[string]*net.UDPAddr // eager-forward neighbors, len <= meshSize
+	all   map[string]*net.UDPAddr // every room peer we know of
+	seen  map[ID]time.Time
+	store map[ID]Message // bodies kept only while seen[id] hasn't expired
+
+	stop chan struct{}
+}
+
+// NewRouter creates a Router that signs outgoing messages with priv and
+// hands every newly-seen message received from a peer to onDeliver;
+// messages we Publish ourselves are not passed to onDeliver.
+// Forwarding happens by calling send with the raw frame to forward.
+func NewRouter(priv ed25519.PrivateKey, send func(addr *net.UDPAddr, frame []byte), onDeliver func(Message)) *Router {
+	r := &Router{
+		priv:      priv,
+		send:      send,
+		onDeliver: onDeliver,
+		mesh:      make(map[string]*net.UDPAddr),
+		all:       make(map[string]*net.UDPAddr),
+		seen:      make(map[ID]time.Time),
+		store:     make(map[ID]Message),
+		stop:      make(chan struct{}),
+	}
+	wire.RegisterCodec(wire.CodeChat, r.handleGossipMessage)
+	wire.RegisterCodec(CodeIHave, r.handleIHave)
+	wire.RegisterCodec(CodeIWant, r.handleIWant)
+	go r.gossipLoop()
+	return r
+}
+
+// Close stops the background gossip loop.
+func (r *Router) Close() { close(r.stop) }
+
+// AddPeer makes addr eligible to receive gossip, joining the mesh
+// directly if it has room.
+func (r *Router) AddPeer(addr *net.UDPAddr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := addr.String()
+	if _, ok := r.all[key]; ok {
+		return
+	}
+	r.all[key] = addr
+	if len(r.mesh) < meshSize {
+		r.mesh[key] = addr
+	}
+}
+
+// RemovePeer drops addr from the mesh, backfilling from the remaining
+// known peers so the mesh heals back towards meshSize.
+func (r *Router) RemovePeer(addr *net.UDPAddr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := addr.String()
+	delete(r.all, key)
+	if _, inMesh := r.mesh[key]; !inMesh {
+		return
+	}
+	delete(r.mesh, key)
+	for k, a := range r.all {
+		if _, already := r.mesh[k]; already {
+			continue
+		}
+		r.mesh[k] = a
+		if len(r.mesh) >= meshSize {
+			break
+		}
+	}
+}
+
+// Publish signs payload as the next message in our sequence and
+// forwards it to our mesh neighbors.
+func (r *Router) Publish(payload []byte) {
+	r.mu.Lock()
+	seq := r.seq
+	r.seq++
+	r.mu.Unlock()
+
+	msg := Sign(r.priv, seq, payload)
+	r.markSeen(msg)
+	r.forward(msg, "")
+}
+
+func (r *Router) handleGossipMessage(from *net.UDPAddr, body []byte) {
+	msg, err := Decode(body)
+	if err != nil || !msg.Verify() {
+		return
+	}
+	id := msg.ID()
+
+	r.mu.Lock()
+	_, dup := r.seen[id]
+	r.mu.Unlock()
+	if dup {
+		return
+	}
+
+	r.markSeen(msg)
+	r.onDeliver(msg)
+	r.forward(msg, from.String())
+}
+
+// forward relays msg to every mesh neighbor except the one it arrived
+// from (if any), which is what keeps one message from bouncing
+// straight back to its sender.
+func (r *Router) forward(msg Message, exceptKey string) {
+	frame := wire.Encode(wire.CodeChat, 0, msg.Encode())
+
+	r.mu.Lock()
+	targets := make([]*net.UDPAddr, 0, len(r.mesh))
+	for k, a := range r.mesh {
+		if k == exceptKey {
+			continue
+		}
+		targets = append(targets, a)
+	}
+	r.mu.Unlock()
+
+	for _, a := range targets {
+		r.send(a, frame)
+	}
+}
+
+func (r *Router) markSeen(msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen[msg.ID()] = time.Now()
+	r.store[msg.ID()] = msg
+}
+
+func (r *Router) gossipLoop() {
+	ticker := time.NewTicker(gossipInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.pruneSeen()
+			r.gossipOnce()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *Router) pruneSeen() {
+	cutoff := time.Now().Add(-seenTTL)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, seenAt := range r.seen {
+		if seenAt.Before(cutoff) {
+			delete(r.seen, id)
+			delete(r.store, id)
+		}
+	}
+}
+
+// gossipOnce tells every room peer we are not already forwarding to
+// eagerly which message IDs we have, so they can pull anything their
+// own mesh links missed.
+func (r *Router) gossipOnce() {
+	r.mu.Lock()
+	var ids []ID
+	for id := range r.seen {
+		ids = append(ids, id)
+	}
+	var targets []*net.UDPAddr
+	for k, a := range r.all {
+		if _, inMesh := r.mesh[k]; inMesh {
+			continue
+		}
+		targets = append(targets, a)
+	}
+	r.mu.Unlock()
+
+	if len(ids) == 0 || len(targets) == 0 {
+		return
+	}
+	frame := wire.Encode(CodeIHave, 0, encodeIDs(ids))
+	for _, a := range targets {
+		r.send(a, frame)
+	}
+}
+
+func (r *Router) handleIHave(from *net.UDPAddr, body []byte) {
+	ids, err := decodeIDs(body)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	var want []ID
+	for _, id := range ids {
+		if _, ok := r.seen[id]; !ok {
+			want = append(want, id)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(want) == 0 {
+		return
+	}
+	r.send(from, wire.Encode(CodeIWant, 0, encodeIDs(want)))
+}
+
+func (r *Router) handleIWant(from *net.UDPAddr, body []byte) {
+	ids, err := decodeIDs(body)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	var msgs []Message
+	for _, id := range ids {
+		if msg, ok := r.store[id]; ok {
+			msgs = append(msgs, msg)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, msg := range msgs {
+		r.send(from, wire.Encode(wire.CodeChat, 0, msg.Encode()))
+	}
+}
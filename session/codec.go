@@ -0,0 +1,75 @@
+package session
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+)
+
+// EncodeHello serializes a Hello into a flat byte string for
+// transmission as the body of a wire.CodeHandshake frame.
+func EncodeHello(h Hello) []byte {
+	out := make([]byte, 0, 32+32+8+len(h.StaticSign)+len(h.Sig)+8)
+	out = append(out, h.EphemeralPub[:]...)
+	out = append(out, h.StaticX[:]...)
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(h.Timestamp))
+	out = append(out, ts[:]...)
+
+	var sigLen [2]byte
+	binary.BigEndian.PutUint16(sigLen[:], uint16(len(h.Sig)))
+	out = append(out, sigLen[:]...)
+	out = append(out, h.StaticSign...)
+	out = append(out, h.Sig...)
+
+	out = append(out, byte(len(h.Transports)))
+	for _, t := range h.Transports {
+		var tLen [2]byte
+		binary.BigEndian.PutUint16(tLen[:], uint16(len(t)))
+		out = append(out, tLen[:]...)
+		out = append(out, t...)
+	}
+	return out
+}
+
+// DecodeHello is the inverse of EncodeHello.
+func DecodeHello(data []byte) (Hello, error) {
+	var h Hello
+	if len(data) < 32+32+8+2+ed25519.PublicKeySize {
+		return h, fmt.Errorf("session: hello too short")
+	}
+	copy(h.EphemeralPub[:], data[0:32])
+	copy(h.StaticX[:], data[32:64])
+	h.Timestamp = int64(binary.BigEndian.Uint64(data[64:72]))
+	sigLen := int(binary.BigEndian.Uint16(data[72:74]))
+
+	rest := data[74:]
+	if len(rest) < ed25519.PublicKeySize+sigLen {
+		return h, fmt.Errorf("session: hello truncated")
+	}
+	h.StaticSign = append(ed25519.PublicKey(nil), rest[:ed25519.PublicKeySize]...)
+	h.Sig = append([]byte(nil), rest[ed25519.PublicKeySize:ed25519.PublicKeySize+sigLen]...)
+	rest = rest[ed25519.PublicKeySize+sigLen:]
+
+	if len(rest) < 1 {
+		return h, fmt.Errorf("session: hello missing transport count")
+	}
+	count := int(rest[0])
+	rest = rest[1:]
+
+	h.Transports = make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		if len(rest) < 2 {
+			return h, fmt.Errorf("session: hello truncated transport")
+		}
+		tLen := int(binary.BigEndian.Uint16(rest[:2]))
+		rest = rest[2:]
+		if len(rest) < tLen {
+			return h, fmt.Errorf("session: hello truncated transport")
+		}
+		h.Transports = append(h.Transports, string(rest[:tLen]))
+		rest = rest[tLen:]
+	}
+	return h, nil
+}
@@ -0,0 +1,163 @@
+package session
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// hkdfInfo is mixed into key derivation so sessions from this protocol
+// version never collide with keys derived by some future wire format.
+const hkdfInfo = "golang-p2p-v1"
+
+// handshakeTTL bounds how stale a HELLO's timestamp may be before it is
+// rejected as a replay of a captured handshake.
+const handshakeTTL = 30 * time.Second
+
+// Hello is the single message both sides of the handshake exchange. The
+// pattern is deliberately symmetric: initiator and responder send the
+// exact same shape, and role is resolved afterwards by comparing static
+// keys, so there is no way to confuse "first message" with "initiator".
+type Hello struct {
+	EphemeralPub [32]byte
+	StaticSign   ed25519.PublicKey // identity used to verify Sig
+	StaticX      [32]byte          // identity's X25519 key, used for ECDH
+	Timestamp    int64
+	Transports   []string // multiaddr-like strings (see transport.Multiaddr) this peer can be reached on
+	Sig          []byte   // sig(ephemeral_pub || static_x || timestamp || transports) under StaticSign
+}
+
+// Handshake drives the two-message authenticated key exchange for one
+// peer. Call NewHandshake to produce the outbound Hello, then Finish once
+// the peer's Hello has arrived to derive the Session.
+type Handshake struct {
+	id       *Identity
+	ephPriv  [32]byte
+	ephPub   [32]byte
+	outHello Hello
+}
+
+// NewHandshake generates a fresh ephemeral keypair and prepares this
+// peer's half of the exchange. transports is advertised to the peer as
+// the multiaddrs we can be reached on (see transport.Multiaddr), so the
+// dialer can pick the best one they have in common.
+func NewHandshake(id *Identity, transports []string) (*Handshake, error) {
+	var ephPriv [32]byte
+	if _, err := io.ReadFull(rand.Reader, ephPriv[:]); err != nil {
+		return nil, fmt.Errorf("session: ephemeral key: %w", err)
+	}
+	ephPub, err := curve25519.X25519(ephPriv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("session: derive ephemeral pub: %w", err)
+	}
+
+	h := &Handshake{id: id}
+	copy(h.ephPriv[:], ephPriv[:])
+	copy(h.ephPub[:], ephPub)
+
+	h.outHello = Hello{
+		EphemeralPub: h.ephPub,
+		StaticSign:   id.Pub,
+		StaticX:      id.XPub,
+		Timestamp:    time.Now().Unix(),
+		Transports:   transports,
+	}
+	h.outHello.Sig = ed25519.Sign(id.Priv, signedBytes(h.outHello.EphemeralPub, h.outHello.StaticX, h.outHello.Timestamp, h.outHello.Transports))
+	return h, nil
+}
+
+// Hello returns the message to send to the peer (wire-encoded as a
+// HANDSHAKE frame by the caller).
+func (h *Handshake) Hello() Hello { return h.outHello }
+
+func signedBytes(ephPub, staticX [32]byte, timestamp int64, transports []string) []byte {
+	buf := make([]byte, 0, 72)
+	buf = append(buf, ephPub[:]...)
+	buf = append(buf, staticX[:]...)
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(timestamp))
+	buf = append(buf, ts[:]...)
+	// NUL-separated: "/"-delimited multiaddrs never contain a NUL byte,
+	// so this can't be ambiguous the way joining with another multiaddr
+	// separator could be.
+	for _, t := range transports {
+		buf = append(buf, t...)
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// Finish verifies the peer's Hello and derives a Session from the
+// ephemeral-ephemeral, static-ephemeral and ephemeral-static ECDH terms,
+// binding the result to both identities the way a Noise XX-ish pattern
+// would.
+func (h *Handshake) Finish(peer Hello) (*Session, error) {
+	age := time.Since(time.Unix(peer.Timestamp, 0))
+	if age > handshakeTTL || age < -handshakeTTL {
+		return nil, fmt.Errorf("session: handshake timestamp out of range (replay?)")
+	}
+	if len(peer.StaticSign) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("session: malformed static key")
+	}
+	if !ed25519.Verify(peer.StaticSign, signedBytes(peer.EphemeralPub, peer.StaticX, peer.Timestamp, peer.Transports), peer.Sig) {
+		return nil, fmt.Errorf("session: handshake signature invalid")
+	}
+
+	eeShared, err := curve25519.X25519(h.ephPriv[:], peer.EphemeralPub[:])
+	if err != nil {
+		return nil, fmt.Errorf("session: ee ecdh: %w", err)
+	}
+	seShared, err := curve25519.X25519(h.id.xPriv[:], peer.EphemeralPub[:])
+	if err != nil {
+		return nil, fmt.Errorf("session: se ecdh: %w", err)
+	}
+	esShared, err := curve25519.X25519(h.ephPriv[:], peer.StaticX[:])
+	if err != nil {
+		return nil, fmt.Errorf("session: es ecdh: %w", err)
+	}
+
+	// se/es are each one side's (static, ephemeral) ECDH term, and ECDH
+	// is symmetric: my seShared (my static x their ephemeral) equals
+	// their esShared (their ephemeral x my static), and vice versa. So
+	// the two peers must concatenate these two terms in the same
+	// relative order rather than each always putting "my se first" —
+	// otherwise they derive different ikm. Order by the same static-key
+	// compare used for the tx/rx split below.
+	ikm := make([]byte, 0, 96)
+	ikm = append(ikm, eeShared...)
+	if bytes.Compare(h.id.Pub, peer.StaticSign) < 0 {
+		ikm = append(ikm, seShared...)
+		ikm = append(ikm, esShared...)
+	} else {
+		ikm = append(ikm, esShared...)
+		ikm = append(ikm, seShared...)
+	}
+
+	okm := make([]byte, 64)
+	kdf := hkdf.New(sha256.New, ikm, nil, []byte(hkdfInfo))
+	if _, err := io.ReadFull(kdf, okm); err != nil {
+		return nil, fmt.Errorf("session: hkdf: %w", err)
+	}
+
+	// Order tx/rx by lexicographic compare of static keys so both peers
+	// agree on which half of okm encrypts which direction, without
+	// needing to know who dialed whom.
+	var txKey, rxKey [32]byte
+	if bytes.Compare(h.id.Pub, peer.StaticSign) < 0 {
+		copy(txKey[:], okm[:32])
+		copy(rxKey[:], okm[32:])
+	} else {
+		copy(txKey[:], okm[32:])
+		copy(rxKey[:], okm[:32])
+	}
+
+	return newSession(peer.StaticSign, txKey, rxKey)
+}
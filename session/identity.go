@@ -0,0 +1,115 @@
+// Package session gives each peer a long-lived identity and turns a raw
+// UDP address into an authenticated, encrypted channel.
+package session
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// identityFileSize is the seed for the ed25519 signing key followed by
+// the scalar for the long-lived X25519 ECDH key.
+const identityFileSize = ed25519.SeedSize + 32
+
+// Identity is a peer's long-lived keypair pair: an ed25519 key used to
+// sign handshake messages, and an X25519 key used for the static ECDH
+// terms. Keeping them separate avoids any need to convert between the
+// two curves.
+type Identity struct {
+	Priv ed25519.PrivateKey
+	Pub  ed25519.PublicKey
+
+	xPriv [32]byte
+	XPub  [32]byte
+}
+
+// Fingerprint returns a short hex string suitable for out-of-band
+// verification (shown in the TUI sidebar next to a peer's name).
+func (id *Identity) Fingerprint() string {
+	return Fingerprint(id.Pub)
+}
+
+// Fingerprint renders any ed25519 public key the same short, human
+// comparable way as Identity.Fingerprint, so peers shown in the TUI
+// sidebar can be verified out of band.
+func Fingerprint(pub ed25519.PublicKey) string {
+	return hex.EncodeToString(pub)[:16]
+}
+
+// LoadIdentity reads an identity from keyPath, generating and persisting a
+// fresh one if the file does not exist yet. This mirrors the
+// `-nodekey`/`-genkey` flags of go-ethereum's bootnode: a stable identity
+// across restarts without requiring the operator to manage keys by hand.
+func LoadIdentity(keyPath string) (*Identity, error) {
+	if data, err := os.ReadFile(keyPath); err == nil {
+		return identityFromFile(data)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("session: read identity: %w", err)
+	}
+
+	id, err := GenIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	if dir := filepath.Dir(keyPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("session: create key dir: %w", err)
+		}
+	}
+	if err := os.WriteFile(keyPath, id.marshal(), 0o600); err != nil {
+		return nil, fmt.Errorf("session: persist identity: %w", err)
+	}
+	return id, nil
+}
+
+func (id *Identity) marshal() []byte {
+	out := make([]byte, 0, identityFileSize)
+	out = append(out, id.Priv.Seed()...)
+	out = append(out, id.xPriv[:]...)
+	return out
+}
+
+func identityFromFile(data []byte) (*Identity, error) {
+	if len(data) != identityFileSize {
+		return nil, fmt.Errorf("session: identity file has %d bytes, want %d", len(data), identityFileSize)
+	}
+	priv := ed25519.NewKeyFromSeed(data[:ed25519.SeedSize])
+	id := &Identity{Priv: priv, Pub: priv.Public().(ed25519.PublicKey)}
+	copy(id.xPriv[:], data[ed25519.SeedSize:])
+	xPub, err := curve25519.X25519(id.xPriv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("session: derive static X25519 pub: %w", err)
+	}
+	copy(id.XPub[:], xPub)
+	return id, nil
+}
+
+// GenIdentity creates a fresh, unpersisted identity. Useful for the
+// introducer, which has no on-disk state of its own today.
+func GenIdentity() (*Identity, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("session: generate identity: %w", err)
+	}
+
+	var xPriv [32]byte
+	if _, err := io.ReadFull(rand.Reader, xPriv[:]); err != nil {
+		return nil, fmt.Errorf("session: generate static X25519 key: %w", err)
+	}
+	xPub, err := curve25519.X25519(xPriv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("session: derive static X25519 pub: %w", err)
+	}
+
+	id := &Identity{Priv: priv, Pub: pub, xPriv: xPriv}
+	copy(id.XPub[:], xPub)
+	return id, nil
+}
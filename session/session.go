@@ -0,0 +1,121 @@
+package session
+
+import (
+	"crypto/cipher"
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// replayWindow is the number of recent receive nonces we remember; a
+// datagram older than the lowest nonce in the window is rejected
+// outright rather than tracked individually.
+const replayWindow = 1024
+
+// Session is an established, authenticated channel to one peer: a pair
+// of ChaCha20-Poly1305 AEAD contexts (one per direction) plus the
+// monotonic nonces and replay bookkeeping needed to use them safely over
+// an unordered transport like UDP.
+type Session struct {
+	PeerKey ed25519.PublicKey
+
+	mu       sync.Mutex
+	tx       cipher.AEAD
+	rx       cipher.AEAD
+	sendSeq  uint64
+	recvSeen map[uint64]struct{}
+	recvLo   uint64
+}
+
+func newSession(peerKey ed25519.PublicKey, txKey, rxKey [32]byte) (*Session, error) {
+	tx, err := chacha20poly1305.New(txKey[:])
+	if err != nil {
+		return nil, fmt.Errorf("session: init tx aead: %w", err)
+	}
+	rx, err := chacha20poly1305.New(rxKey[:])
+	if err != nil {
+		return nil, fmt.Errorf("session: init rx aead: %w", err)
+	}
+	return &Session{
+		PeerKey:  peerKey,
+		tx:       tx,
+		rx:       rx,
+		recvSeen: make(map[uint64]struct{}, replayWindow),
+	}, nil
+}
+
+// Seal encrypts and authenticates plaintext under the next send nonce,
+// returning `nonce(8) || ciphertext`.
+func (s *Session) Seal(plaintext []byte) []byte {
+	s.mu.Lock()
+	nonce := s.sendSeq
+	s.sendSeq++
+	s.mu.Unlock()
+
+	nonceBytes := nonceFor(nonce)
+	out := make([]byte, 8, 8+len(plaintext)+chacha20poly1305.Overhead)
+	binary.BigEndian.PutUint64(out, nonce)
+	return s.tx.Seal(out, nonceBytes[:], plaintext, nil)
+}
+
+// Open verifies and decrypts a datagram produced by Seal, rejecting it
+// if the embedded nonce has been seen before (a replay) or falls below
+// the sliding window of nonces we still track.
+func (s *Session) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < 8 {
+		return nil, fmt.Errorf("session: sealed message too short")
+	}
+	nonce := binary.BigEndian.Uint64(sealed[:8])
+
+	s.mu.Lock()
+	if err := s.checkReplay(nonce); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.mu.Unlock()
+
+	nonceBytes := nonceFor(nonce)
+	plaintext, err := s.rx.Open(nil, nonceBytes[:], sealed[8:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("session: auth failed: %w", err)
+	}
+
+	s.mu.Lock()
+	s.markSeen(nonce)
+	s.mu.Unlock()
+	return plaintext, nil
+}
+
+// checkReplay must be called with s.mu held.
+func (s *Session) checkReplay(nonce uint64) error {
+	if nonce < s.recvLo {
+		return fmt.Errorf("session: replayed or too-old nonce %d", nonce)
+	}
+	if _, seen := s.recvSeen[nonce]; seen {
+		return fmt.Errorf("session: replayed nonce %d", nonce)
+	}
+	return nil
+}
+
+// markSeen must be called with s.mu held, after a successful Open.
+func (s *Session) markSeen(nonce uint64) {
+	s.recvSeen[nonce] = struct{}{}
+	if nonce >= s.recvLo+replayWindow {
+		newLo := nonce - replayWindow + 1
+		for n := range s.recvSeen {
+			if n < newLo {
+				delete(s.recvSeen, n)
+			}
+		}
+		s.recvLo = newLo
+	}
+}
+
+func nonceFor(seq uint64) [chacha20poly1305.NonceSize]byte {
+	var n [chacha20poly1305.NonceSize]byte
+	binary.BigEndian.PutUint64(n[chacha20poly1305.NonceSize-8:], seq)
+	return n
+}
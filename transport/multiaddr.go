@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Multiaddr is a minimal, libp2p-inspired address format: a sequence of
+// /protocol/value segments, e.g. "/ip4/1.2.3.4/udp/5555/quic". One
+// string names both an address and which transport to reach it with,
+// instead of needing a separate field per transport a peer supports.
+type Multiaddr string
+
+// protocolPriority ranks transports best-first: quic gets multiplexed
+// streams and TLS 1.3 for free, tcp is at least reliable, udp is the
+// fallback every peer is assumed to support.
+var protocolPriority = []string{"quic", "tcp", "udp"}
+
+// New builds the Multiaddr for host:port reachable over the named
+// transport proto ("udp", "tcp", or "quic"). quic, like udp, is reached
+// over a UDP socket — /quic is an extra segment marking which protocol
+// speaks on top of it, not a different socket family.
+func New(proto, host string, port int) (Multiaddr, error) {
+	switch proto {
+	case "udp":
+		return Multiaddr(fmt.Sprintf("/ip4/%s/udp/%d", host, port)), nil
+	case "quic":
+		return Multiaddr(fmt.Sprintf("/ip4/%s/udp/%d/quic", host, port)), nil
+	case "tcp":
+		return Multiaddr(fmt.Sprintf("/ip4/%s/tcp/%d", host, port)), nil
+	default:
+		return "", fmt.Errorf("transport: unknown protocol %q", proto)
+	}
+}
+
+// Proto returns the transport name m should be dialed with.
+func (m Multiaddr) Proto() string {
+	parts := strings.Split(string(m), "/")
+	if len(parts) >= 6 && parts[5] == "quic" {
+		return "quic"
+	}
+	if len(parts) >= 4 && parts[3] == "tcp" {
+		return "tcp"
+	}
+	return "udp"
+}
+
+// HostPort returns the "ip:port" a Transport.Dial/Listen expects.
+func (m Multiaddr) HostPort() (string, error) {
+	parts := strings.Split(string(m), "/")
+	if len(parts) < 5 || parts[1] != "ip4" {
+		return "", fmt.Errorf("transport: malformed multiaddr %q", m)
+	}
+	if _, err := strconv.Atoi(parts[4]); err != nil {
+		return "", fmt.Errorf("transport: malformed multiaddr %q", m)
+	}
+	return net.JoinHostPort(parts[2], parts[4]), nil
+}
+
+// Best picks the highest-priority transport both mine and theirs
+// advertise, returning the Multiaddr (theirs) to dial. It errors if the
+// two share no transport at all, which given every peer is expected to
+// advertise udp should only happen against a malformed remote list.
+func Best(mine, theirs []Multiaddr) (Multiaddr, error) {
+	theirsByProto := make(map[string]Multiaddr, len(theirs))
+	for _, m := range theirs {
+		theirsByProto[m.Proto()] = m
+	}
+	mineProtos := make(map[string]bool, len(mine))
+	for _, m := range mine {
+		mineProtos[m.Proto()] = true
+	}
+
+	for _, proto := range protocolPriority {
+		if !mineProtos[proto] {
+			continue
+		}
+		if m, ok := theirsByProto[proto]; ok {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("transport: no mutually supported transport")
+}
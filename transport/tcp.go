@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// maxFrameSize bounds the length prefix tcpConn.Read trusts off the
+// wire, so a peer can't make us allocate gigabytes for one message by
+// claiming an absurd length.
+const maxFrameSize = 16 << 20
+
+// tcpTransport implements Transport over TCP, framing each Write as a
+// 4-byte big-endian length prefix followed by the payload — TCP has no
+// message boundaries of its own, unlike raw UDP datagrams or QUIC
+// streams.
+type tcpTransport struct{}
+
+// TCP is the tcp Transport.
+var TCP Transport = tcpTransport{}
+
+func (tcpTransport) Name() string { return "tcp" }
+
+func (tcpTransport) Dial(ctx context.Context, addr string) (Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("transport/tcp: dial: %w", err)
+	}
+	return &tcpConn{conn: conn}, nil
+}
+
+func (tcpTransport) Listen(addr string) (Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("transport/tcp: listen: %w", err)
+	}
+	return &tcpListener{ln: ln}, nil
+}
+
+type tcpConn struct {
+	conn net.Conn
+}
+
+func (c *tcpConn) Read() ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(c.conn, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(lenBuf[:])
+	if length > maxFrameSize {
+		return nil, fmt.Errorf("transport/tcp: frame length %d exceeds max %d", length, maxFrameSize)
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (c *tcpConn) Write(msg []byte) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(msg)))
+	if _, err := c.conn.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(msg)
+	return err
+}
+
+func (c *tcpConn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+func (c *tcpConn) Close() error         { return c.conn.Close() }
+
+type tcpListener struct {
+	ln net.Listener
+}
+
+func (l *tcpListener) Accept() (Conn, error) {
+	conn, err := l.ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &tcpConn{conn: conn}, nil
+}
+
+func (l *tcpListener) Close() error   { return l.ln.Close() }
+func (l *tcpListener) Addr() net.Addr { return l.ln.Addr() }
@@ -0,0 +1,59 @@
+// Package transport abstracts the network connection a peer dials or
+// listens on, so the rest of the stack can be written against Conn and
+// Listener instead of net.UDPConn directly. Three implementations are
+// provided: udp (today's unreliable datagrams), tcp (length-prefixed
+// framing over a stream), and quic (multiplexed streams with built-in
+// TLS 1.3 via quic-go). Each peer advertises the multiaddrs it supports
+// in its handshake (see session.Hello.Transports); Best picks the
+// highest-priority one both sides have in common.
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// Conn is a single logical connection to a peer, regardless of which
+// Transport carries it. Reads and writes are message-framed: one Write
+// call is delivered as exactly one Read call on the other end, the same
+// guarantee raw UDP gives for free and that tcp/quic have to frame for
+// themselves.
+type Conn interface {
+	Read() ([]byte, error)
+	Write(msg []byte) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+// Listener accepts incoming Conns.
+type Listener interface {
+	Accept() (Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
+// Transport dials and listens for one kind of underlying network
+// connection.
+type Transport interface {
+	// Name is the multiaddr protocol segment this transport advertises
+	// itself as — "udp", "tcp", or "quic".
+	Name() string
+	Dial(ctx context.Context, addr string) (Conn, error)
+	Listen(addr string) (Listener, error)
+}
+
+// ByName returns the Transport implementation for a Multiaddr.Proto()
+// value.
+func ByName(name string) (Transport, error) {
+	switch name {
+	case "udp":
+		return UDP, nil
+	case "tcp":
+		return TCP, nil
+	case "quic":
+		return QUIC, nil
+	default:
+		return nil, fmt.Errorf("transport: unknown transport %q", name)
+	}
+}
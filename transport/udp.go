@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// udpTransport implements Transport over raw UDP datagrams: the
+// behavior every peer has used directly until now, wrapped behind Conn
+// so callers no longer have to care which transport they're on.
+type udpTransport struct{}
+
+// UDP is the udp Transport.
+var UDP Transport = udpTransport{}
+
+func (udpTransport) Name() string { return "udp" }
+
+func (udpTransport) Dial(ctx context.Context, addr string) (Conn, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("transport/udp: resolve: %w", err)
+	}
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, fmt.Errorf("transport/udp: dial: %w", err)
+	}
+	return &udpConn{conn: conn, remote: raddr}, nil
+}
+
+func (udpTransport) Listen(addr string) (Listener, error) {
+	laddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("transport/udp: resolve: %w", err)
+	}
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, fmt.Errorf("transport/udp: listen: %w", err)
+	}
+	l := &udpListener{
+		conn:   conn,
+		accept: make(chan Conn, 16),
+		byAddr: make(map[string]*udpConn),
+	}
+	go l.readLoop()
+	return l, nil
+}
+
+// udpConn is one peer's side of a udp Conn. A dialed udpConn owns its
+// own connected socket; an accepted one shares its listener's socket
+// and is fed incoming datagrams through incoming.
+type udpConn struct {
+	conn     *net.UDPConn // set when we dialed out
+	listener *udpListener // set when we were accepted from a Listener
+	remote   *net.UDPAddr
+	incoming chan []byte // non-nil when accepted
+}
+
+func (c *udpConn) Read() ([]byte, error) {
+	if c.incoming != nil {
+		msg, ok := <-c.incoming
+		if !ok {
+			return nil, fmt.Errorf("transport/udp: connection closed")
+		}
+		return msg, nil
+	}
+	buf := make([]byte, 65535)
+	n, err := c.conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (c *udpConn) Write(msg []byte) error {
+	if c.listener != nil {
+		_, err := c.listener.conn.WriteToUDP(msg, c.remote)
+		return err
+	}
+	_, err := c.conn.Write(msg)
+	return err
+}
+
+func (c *udpConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *udpConn) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// udpListener demultiplexes one shared UDP socket into one Conn per
+// remote address, since UDP itself has no notion of Accept: the first
+// datagram from a new address creates a Conn and hands it to Accept,
+// every later datagram from that address is delivered to the same Conn.
+type udpListener struct {
+	conn   *net.UDPConn
+	accept chan Conn
+
+	mu     sync.Mutex
+	byAddr map[string]*udpConn
+}
+
+func (l *udpListener) readLoop() {
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := l.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		msg := append([]byte(nil), buf[:n]...)
+
+		l.mu.Lock()
+		c, ok := l.byAddr[from.String()]
+		if !ok {
+			c = &udpConn{listener: l, remote: from, incoming: make(chan []byte, 64)}
+			l.byAddr[from.String()] = c
+		}
+		l.mu.Unlock()
+		if !ok {
+			l.accept <- c
+		}
+		c.incoming <- msg
+	}
+}
+
+func (l *udpListener) Accept() (Conn, error) {
+	c, ok := <-l.accept
+	if !ok {
+		return nil, fmt.Errorf("transport/udp: listener closed")
+	}
+	return c, nil
+}
+
+func (l *udpListener) Close() error   { return l.conn.Close() }
+func (l *udpListener) Addr() net.Addr { return l.conn.LocalAddr() }
@@ -0,0 +1,59 @@
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Bodies use a small length-prefixed encoding rather than full
+// protobuf/RLP: every field is either a fixed-size value or prefixed
+// with its length, which is all these messages need and keeps this
+// package dependency-free.
+
+// bodyWriter accumulates fields into a message body.
+type bodyWriter struct {
+	buf []byte
+}
+
+func newBodyWriter() *bodyWriter { return &bodyWriter{} }
+
+func (w *bodyWriter) bytes(b []byte) *bodyWriter {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(b)))
+	w.buf = append(w.buf, length[:]...)
+	w.buf = append(w.buf, b...)
+	return w
+}
+
+func (w *bodyWriter) string(s string) *bodyWriter { return w.bytes([]byte(s)) }
+
+func (w *bodyWriter) Bytes() []byte { return w.buf }
+
+// bodyReader consumes fields in the order bodyWriter wrote them.
+type bodyReader struct {
+	buf []byte
+}
+
+func newBodyReader(b []byte) *bodyReader { return &bodyReader{buf: b} }
+
+func (r *bodyReader) bytes() ([]byte, error) {
+	if len(r.buf) < 4 {
+		return nil, fmt.Errorf("wire: truncated length prefix")
+	}
+	length := binary.BigEndian.Uint32(r.buf[:4])
+	r.buf = r.buf[4:]
+	if uint32(len(r.buf)) < length {
+		return nil, fmt.Errorf("wire: truncated field (want %d, have %d)", length, len(r.buf))
+	}
+	out := r.buf[:length]
+	r.buf = r.buf[length:]
+	return out, nil
+}
+
+func (r *bodyReader) string() (string, error) {
+	b, err := r.bytes()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
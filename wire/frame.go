@@ -0,0 +1,123 @@
+// Package wire defines the on-the-wire framing shared by every UDP
+// datagram the introducer and peers exchange. It replaces the old
+// line-oriented "MSG ..."/"PUNCH ..." ASCII protocol, which broke on
+// newlines or binary payloads and had no room to grow, with a small
+// fixed header followed by a message-specific body.
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Magic distinguishes our datagrams from noise arriving on the same
+// port and lets a future version bump fail fast instead of misparsing.
+const Magic uint32 = 0x70327030 // "p2p0"
+
+// Version is the current wire format version. Frames with a different
+// version are rejected rather than guessed at.
+const Version uint8 = 1
+
+// headerSize is magic(4) + version(1) + code(2) + flags(1) + length(4).
+const headerSize = 4 + 1 + 2 + 1 + 4
+
+// Code identifies the kind of message a Frame carries, so the receiver
+// can dispatch on it without parsing the body first.
+type Code uint16
+
+const (
+	CodeJoin Code = iota + 1
+	CodeLeave
+	CodePunch
+	CodePeerList
+	CodeChat
+	CodeAck
+	CodePing
+	CodePong
+	CodeHandshake
+	CodeSealed
+)
+
+func (c Code) String() string {
+	switch c {
+	case CodeJoin:
+		return "JOIN"
+	case CodeLeave:
+		return "LEAVE"
+	case CodePunch:
+		return "PUNCH"
+	case CodePeerList:
+		return "PEERLIST"
+	case CodeChat:
+		return "CHAT"
+	case CodeAck:
+		return "ACK"
+	case CodePing:
+		return "PING"
+	case CodePong:
+		return "PONG"
+	case CodeHandshake:
+		return "HANDSHAKE"
+	case CodeSealed:
+		return "SEALED"
+	default:
+		return fmt.Sprintf("Code(%d)", uint16(c))
+	}
+}
+
+// Flags are per-frame bits reserved for future use (e.g. "needs ACK").
+// None are defined yet; a handler should ignore bits it doesn't know.
+type Flags uint8
+
+// Frame is one decoded datagram: header fields plus an opaque body that
+// the handler registered for Code is responsible for interpreting.
+type Frame struct {
+	Version uint8
+	Code    Code
+	Flags   Flags
+	Body    []byte
+}
+
+// Encode serializes code, flags and body into a header-prefixed
+// datagram ready to send.
+func Encode(code Code, flags Flags, body []byte) []byte {
+	out := make([]byte, headerSize+len(body))
+	binary.BigEndian.PutUint32(out[0:4], Magic)
+	out[4] = Version
+	binary.BigEndian.PutUint16(out[5:7], uint16(code))
+	out[7] = byte(flags)
+	binary.BigEndian.PutUint32(out[8:12], uint32(len(body)))
+	copy(out[headerSize:], body)
+	return out
+}
+
+// Decode parses a datagram produced by Encode, validating magic,
+// version and declared length before handing back the Frame.
+func Decode(data []byte) (Frame, error) {
+	var f Frame
+	if len(data) < headerSize {
+		return f, fmt.Errorf("wire: datagram too short (%d bytes)", len(data))
+	}
+	magic := binary.BigEndian.Uint32(data[0:4])
+	if magic != Magic {
+		return f, fmt.Errorf("wire: bad magic %#x", magic)
+	}
+	version := data[4]
+	if version != Version {
+		return f, fmt.Errorf("wire: unsupported version %d", version)
+	}
+	code := Code(binary.BigEndian.Uint16(data[5:7]))
+	flags := Flags(data[7])
+	length := binary.BigEndian.Uint32(data[8:12])
+
+	body := data[headerSize:]
+	if uint32(len(body)) != length {
+		return f, fmt.Errorf("wire: declared length %d does not match body %d", length, len(body))
+	}
+
+	f.Version = version
+	f.Code = code
+	f.Flags = flags
+	f.Body = body
+	return f, nil
+}
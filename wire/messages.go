@@ -0,0 +1,113 @@
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Join is the body of a CodeJoin frame: a peer announcing itself to an
+// introducer (or, once rendezvous is peer-to-peer, to another peer).
+// ReflexiveAddr is the peer's own STUN-discovered public address, which
+// may differ from the source address the introducer observes the JOIN
+// arriving from; the introducer uses it to coordinate hole punching.
+type Join struct {
+	Room          string
+	Name          string
+	Pub           []byte // identity public key
+	ReflexiveAddr string
+}
+
+func (j Join) Encode() []byte {
+	return newBodyWriter().string(j.Room).string(j.Name).bytes(j.Pub).string(j.ReflexiveAddr).Bytes()
+}
+
+func DecodeJoin(body []byte) (Join, error) {
+	r := newBodyReader(body)
+	var j Join
+	var err error
+	if j.Room, err = r.string(); err != nil {
+		return j, err
+	}
+	if j.Name, err = r.string(); err != nil {
+		return j, err
+	}
+	if j.Pub, err = r.bytes(); err != nil {
+		return j, err
+	}
+	if j.ReflexiveAddr, err = r.string(); err != nil {
+		return j, err
+	}
+	return j, nil
+}
+
+// Leave is the body of a CodeLeave frame.
+type Leave struct {
+	Room string
+}
+
+func (l Leave) Encode() []byte { return newBodyWriter().string(l.Room).Bytes() }
+
+func DecodeLeave(body []byte) (Leave, error) {
+	r := newBodyReader(body)
+	room, err := r.string()
+	return Leave{Room: room}, err
+}
+
+// PeerListEntry is one roster entry: an address the introducer knows
+// about, paired with the identity it claims to own.
+type PeerListEntry struct {
+	Addr string
+	Pub  []byte
+}
+
+// PeerList is the body of a CodePeerList frame. It carries either a full
+// room roster (reply to JOIN) or a single newcomer (push to existing
+// members) — the two cases differ only in entry count.
+type PeerList struct {
+	Entries []PeerListEntry
+}
+
+func (p PeerList) Encode() []byte {
+	w := newBodyWriter()
+	var count [4]byte
+	binary.BigEndian.PutUint32(count[:], uint32(len(p.Entries)))
+	w.bytes(count[:])
+	for _, e := range p.Entries {
+		w.string(e.Addr).bytes(e.Pub)
+	}
+	return w.Bytes()
+}
+
+func DecodePeerList(body []byte) (PeerList, error) {
+	r := newBodyReader(body)
+	countField, err := r.bytes()
+	if err != nil {
+		return PeerList{}, err
+	}
+	if len(countField) != 4 {
+		return PeerList{}, fmt.Errorf("wire: malformed peer list count")
+	}
+	count := int(binary.BigEndian.Uint32(countField))
+
+	// count is untrusted; each entry needs at least two 4-byte length
+	// prefixes (for an empty addr and pub), so cap it against what
+	// remains rather than trusting it straight into make, the same
+	// allocation-DoS pattern fixed in pubsub.decodeIDs.
+	if max := len(r.buf) / 8; count > max {
+		return PeerList{}, fmt.Errorf("wire: peer list count %d exceeds data for %d bytes", count, len(r.buf))
+	}
+
+	entries := make([]PeerListEntry, 0, count)
+	for i := 0; i < count; i++ {
+		addr, err := r.string()
+		if err != nil {
+			return PeerList{}, err
+		}
+		pub, err := r.bytes()
+		if err != nil {
+			return PeerList{}, err
+		}
+		entries = append(entries, PeerListEntry{Addr: addr, Pub: pub})
+	}
+	return PeerList{Entries: entries}, nil
+}
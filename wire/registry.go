@@ -0,0 +1,47 @@
+package wire
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Handler processes the body of one received frame from a given
+// address. Handlers are registered per Code so that new subsystems
+// (file transfer, presence, ...) can plug into the same read loop
+// without the core loop knowing anything about them.
+type Handler func(from *net.UDPAddr, body []byte)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[Code]Handler)
+)
+
+// RegisterCodec associates a Handler with a Code. Registering the same
+// Code twice replaces the previous handler, which is convenient for
+// tests but should not happen in normal operation.
+func RegisterCodec(code Code, h Handler) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[code] = h
+}
+
+// Dispatch decodes raw and invokes the Handler registered for its Code.
+// It returns an error if the frame is malformed or no handler is
+// registered, so callers can log unknown codes instead of silently
+// dropping them.
+func Dispatch(from *net.UDPAddr, raw []byte) error {
+	f, err := Decode(raw)
+	if err != nil {
+		return err
+	}
+
+	registryMu.RLock()
+	h, ok := registry[f.Code]
+	registryMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("wire: no handler registered for %s", f.Code)
+	}
+	h(from, f.Body)
+	return nil
+}